@@ -1,19 +1,79 @@
 /* client/src/features/urls/types.ts */
 
+export interface RedirectHop {
+  hop: number
+  url: string
+  status: number
+  location: string
+}
+
 export interface LinkRow {
   href: string
   http_status: number | null
   is_internal: boolean
+  page_id: number | null
+  method: 'HEAD' | 'GET' | null
+  attempts: number
+  error_kind:
+    | ''
+    | 'dns'
+    | 'tls'
+    | 'timeout'
+    | 'refused'
+    | 'reset'
+    | 'too_many_redirects'
+    | 'invalid_url'
+    | 'blocked'
+    | 'other'
+  error_message: string
+  redirect_loop: boolean
+  long_redirect: boolean
+  redirects?: RedirectHop[]
+  check_status: 'checked' | 'blocked_by_robots' | 'unchecked'
+}
+
+export interface PageRow {
+  id: number
+  parent_id: number | null
+  url: string
+  depth: number
+  html_version: string | null
+  title: string | null
+  h1: number
+  h2: number
+  h3: number
+  internal_links: number
+  external_links: number
+  broken_links: number
+  blocked_by_robots: number
+  unchecked_links: number
+  redirected_links: number
+  has_login: boolean
+  children?: PageRow[]
 }
 
 export interface UrlRow {
   id: number
   original_url: string
   title: string | null
-  crawl_status: 'queued' | 'running' | 'done' | 'error'
+  crawl_status: 'queued' | 'running' | 'done' | 'error' | 'cancelled'
+  max_depth: number
+  max_pages: number
+  host_concurrency: number
+  host_interval_ms: number
+  latest_run_id: number | null
+  schedule: string | null
+  schedule_paused: boolean
+  next_run_at: string | null
+  last_run_at: string | null
   internal_links: number
   external_links: number
   broken_links: number
+  blocked_by_robots: number
+  unchecked_links: number
+  redirected_links: number
+  final_url: string | null
+  redirect_loop: boolean
   html_version: string | null
   h1: number
   h2: number
@@ -22,4 +82,77 @@ export interface UrlRow {
   created_at: string
   updated_at: string
   links?: LinkRow[]
+  pages?: PageRow[]
+  redirects?: RedirectHop[]
+}
+
+export interface CrawlRun {
+  id: number
+  url_id: number
+  status: 'running' | 'done' | 'error' | 'cancelled' | 'interrupted'
+  started_at: string
+  finished_at: string | null
+  html_version: string | null
+  title: string | null
+  h1: number
+  h2: number
+  h3: number
+  internal_links: number
+  external_links: number
+  broken_links: number
+  blocked_by_robots: number
+  unchecked_links: number
+  redirected_links: number
+  final_url: string | null
+  redirect_loop: boolean
+  has_login: boolean
+  links?: LinkRow[]
+  pages?: PageRow[]
+  redirects?: RedirectHop[]
+}
+
+/* events of GET /urls/:id/stream and GET /urls/stream; `type` is also the SSE event name */
+export type CrawlStage = 'fetching' | 'parsing' | 'checking_links'
+
+export interface CrawlEvent {
+  id: number
+  url_id: number
+  run_id?: number
+  type: 'progress' | 'wait' | 'stage' | 'link' | 'counts' | 'error' | 'done' | 'status'
+  pct: number
+  status?: UrlRow['crawl_status'] // status events only
+  stage?: CrawlStage
+  page?: string
+  wait_ms?: number
+  link?: {
+    href: string
+    is_internal: boolean
+    check_status: LinkRow['check_status']
+    http_status: number | null
+    error_kind?: LinkRow['error_kind']
+    redirects?: number
+  }
+  counts?: {
+    pages: number
+    internal_links: number
+    external_links: number
+    broken_links: number
+  }
+  error?: string
+  summary?: {
+    status: 'done' | 'error' | 'cancelled' | 'interrupted'
+    pages?: number
+    html_version: string | null
+    title: string | null
+    h1: number
+    h2: number
+    h3: number
+    internal_links: number
+    external_links: number
+    broken_links: number
+    blocked_by_robots: number
+    unchecked_links: number
+    redirected_links: number
+    has_login: boolean
+  }
 }
@@ -13,7 +13,7 @@ export const UrlListSchema = z.object({
     id: z.number(),
     original_url: z.string().url(),
     title: z.string().nullable(),
-    crawl_status: z.enum(['queued', 'running', 'done', 'error']),
+    crawl_status: z.enum(['queued', 'running', 'done', 'error', 'cancelled']),
     internal_links: z.number(),
     external_links: z.number(),
     broken_links: z.number(),
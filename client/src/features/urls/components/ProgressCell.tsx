@@ -1,67 +1,41 @@
 'use client';
 
-import { useEffect, useState } from 'react';
 import { Progress } from '@/components/ui/progress';
 import { Skeleton } from '@/components/ui/skeleton';
-import { apiBase } from '@/features/urls/api';
-import { useAuth } from '@/lib/auth';
-import { useQueryClient } from '@tanstack/react-query';
+import { useLiveCrawl } from '@/features/urls/hooks/useCrawlStream';
+import type { CrawlStage } from '@/features/types';
+
+const stageLabel: Record<CrawlStage, string> = {
+  fetching: 'fetching',
+  parsing: 'parsing',
+  checking_links: 'checking links',
+};
 
 interface Props {
   urlId: number;
-  initialStatus: 'queued' | 'running' | 'done' | 'error';
+  initialStatus: 'queued' | 'running' | 'done' | 'error' | 'cancelled';
 }
 
+// Live updates come from the dashboard's shared stream (CrawlStreamProvider);
+// until one arrives the cell shows the status the table was loaded with.
 export function ProgressCell({ urlId, initialStatus }: Props) {
-  const { token } = useAuth();
-  const qc = useQueryClient();
-  const [pct, setPct] = useState<number | null>(
-    initialStatus === 'done' ? 100
-      : initialStatus === 'error' ? 0
-      : null
-  );
-
-  useEffect(() => {
-    // only listen while it's in-flight
-    if (initialStatus === 'done' || initialStatus === 'error') return;
-
-    const url = new URL(`${apiBase()}/api/v1/urls/${urlId}/stream`);
-    if (token) url.searchParams.set('token', token);
-
-    const es = new EventSource(url.toString());
-
-    es.addEventListener('progress', (e: MessageEvent) => {
-      const v = Number(e.data);
-      if (v >= 100) {
-        es.close();
-        setPct(100);
-        // **<-- invalidate the list so your table refetches**
-        qc.invalidateQueries({ queryKey: ['urls'] });
-      } else {
-        setPct(v);
-      }
-    });
-
-    es.onerror = () => {
-      es.close();
-      setPct(null);
-    };
-
-    return () => {
-      es.close();
-    };
-  }, [urlId, initialStatus, token, qc]);
+  const live = useLiveCrawl(urlId);
+  const status = live?.status ?? initialStatus;
 
-  if (initialStatus === 'done')
+  if (status === 'done')
     return <span className="text-green-600">✅</span>;
-  if (initialStatus === 'error')
+  if (status === 'error')
     return <span className="text-red-600">❌</span>;
-  if (pct === null) return <Skeleton className="h-3 w-20" />;
+  if (status === 'cancelled')
+    return <span className="text-gray-500">⏹</span>;
+  if (!live) return <Skeleton className="h-3 w-20" />;
 
   return (
     <div className="flex items-center gap-2 w-28">
-      <Progress value={pct} className="h-1 flex-1" />
-      <span className="text-xs w-8 text-right">{pct}%</span>
+      <Progress value={live.pct} className="h-1 flex-1" />
+      <span className="text-xs w-8 text-right" title={live.stage ? stageLabel[live.stage] : undefined}>
+        {live.pct}%
+      </span>
     </div>
   );
 }
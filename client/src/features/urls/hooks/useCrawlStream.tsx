@@ -0,0 +1,97 @@
+'use client';
+
+import {
+  createContext,
+  useContext,
+  useEffect,
+  useState,
+  useSyncExternalStore,
+  type ReactNode,
+} from 'react';
+import { useQueryClient } from '@tanstack/react-query';
+import { apiBase } from '@/features/urls/api';
+import { useAuth } from '@/lib/auth';
+import type { CrawlEvent, CrawlStage, UrlRow } from '@/features/types';
+
+/* live state of one URL, folded from GET /api/v1/urls/stream */
+export interface LiveCrawl {
+  status?: UrlRow['crawl_status'];
+  pct: number;
+  stage?: CrawlStage;
+}
+
+const eventTypes: CrawlEvent['type'][] = ['status', 'progress', 'wait', 'stage', 'counts', 'error', 'done'];
+
+class CrawlStore {
+  private state = new Map<number, LiveCrawl>();
+  private listeners = new Set<() => void>();
+
+  subscribe = (fn: () => void) => {
+    this.listeners.add(fn);
+    return () => {
+      this.listeners.delete(fn);
+    };
+  };
+
+  get = (id: number) => this.state.get(id);
+
+  /** returns true when a URL already known moved to another status */
+  apply(e: CrawlEvent): boolean {
+    const prev = this.state.get(e.url_id);
+    const next: LiveCrawl = { ...prev, pct: e.pct };
+    if (e.type === 'status') {
+      next.status = e.status;
+      if (e.status === 'error' || e.status === 'cancelled') next.pct = prev?.pct ?? 0;
+      if (e.status !== 'running') next.stage = undefined;
+    }
+    if (e.type === 'stage') next.stage = e.stage;
+    if (e.type === 'done') {
+      const s = e.summary?.status;
+      // a run cut short by a server shutdown is queued again; the status event says so
+      if (s !== 'interrupted') next.status = s;
+      next.stage = undefined;
+    }
+    this.state.set(e.url_id, next);
+    this.listeners.forEach((fn) => fn());
+    return prev?.status !== undefined && next.status !== prev.status;
+  }
+}
+
+const CrawlStreamContext = createContext<CrawlStore | null>(null);
+
+/** One EventSource for every crawl of the signed-in user. */
+export function CrawlStreamProvider({ children }: { children: ReactNode }) {
+  const { token } = useAuth();
+  const qc = useQueryClient();
+  const [store] = useState(() => new CrawlStore());
+
+  useEffect(() => {
+    if (typeof EventSource === 'undefined') return;
+    const params = token ? `?${new URLSearchParams({ token })}` : '';
+    const es = new EventSource(`${apiBase() ?? ''}/api/v1/urls/stream${params}`);
+
+    const onEvent = (e: MessageEvent) => {
+      // refetch the table when a crawl starts, ends or is stopped
+      if (store.apply(JSON.parse(e.data) as CrawlEvent)) {
+        qc.invalidateQueries({ queryKey: ['urls'] });
+      }
+    };
+    eventTypes.forEach((t) => es.addEventListener(t, onEvent));
+
+    return () => es.close();
+  }, [token, qc, store]);
+
+  return <CrawlStreamContext.Provider value={store}>{children}</CrawlStreamContext.Provider>;
+}
+
+const noop = () => () => {};
+
+/** Live state of urlId, or undefined outside a provider / before any event. */
+export function useLiveCrawl(urlId: number): LiveCrawl | undefined {
+  const store = useContext(CrawlStreamContext);
+  return useSyncExternalStore(
+    store?.subscribe ?? noop,
+    () => store?.get(urlId),
+    () => undefined,
+  );
+}
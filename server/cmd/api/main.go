@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"runtime"
 	"syscall"
 	"time"
 
@@ -18,6 +17,8 @@ import (
 	"github.com/zeewaqar/web-crawler/server/internal/auth"
 	"github.com/zeewaqar/web-crawler/server/internal/crawler"
 	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/env"
+	"github.com/zeewaqar/web-crawler/server/internal/webhook"
 )
 
 func main() {
@@ -27,11 +28,33 @@ func main() {
 		log.Fatal("migrations failed:", err)
 	}
 	auth.Init(os.Getenv("JWT_SECRET"))
+	crawler.Init(crawler.Config{
+		UserAgent:       os.Getenv("CRAWLER_USER_AGENT"),
+		HostConcurrency: env.Int("CRAWLER_HOST_CONCURRENCY"),
+		HostInterval:    env.Duration("CRAWLER_HOST_INTERVAL"), // e.g. "250ms"
+		LinkWorkers:     env.Int("CRAWLER_LINK_WORKERS"),
+		MaxPageBytes:    int64(env.Int("CRAWLER_MAX_PAGE_BYTES")),
 
-	/* 2️⃣  Start crawler workers (2× CPU) */
-	for i := 0; i < runtime.NumCPU()*2; i++ {
-		go crawler.Worker(crawler.Jobs)
+		LongRedirectChain: env.Int("CRAWLER_LONG_REDIRECT_CHAIN"),
+		QueueCapacity:     env.Int("CRAWLER_QUEUE_CAPACITY"),
+		Workers:           env.Int("CRAWLER_WORKERS"), // default 2× CPU, -1 with cmd/worker
+
+		AllowHosts: env.List("CRAWLER_ALLOW_HOSTS"), // e.g. "intranet.local,10.1.0.0/16"
+	})
+	// "db" relays progress & cancels to/from cmd/worker processes
+	if os.Getenv("CRAWLER_BROKER") == "db" {
+		crawler.SetBroker(crawler.NewDBBroker(time.Second))
+	}
+
+	/* 2️⃣  Start crawler worker pool + scheduler */
+	// jobs whose worker died with the last process go back in the queue
+	if err := crawler.Recover(); err != nil {
+		log.Fatal("job recovery failed:", err)
 	}
+	crawler.Workers = crawler.NewPool(0)
+	schedCtx, stopScheduler := context.WithCancel(context.Background())
+	go crawler.Scheduler(schedCtx, 30*time.Second)
+	go webhook.Dispatcher(schedCtx, 5*time.Second) // crawls finished by cmd/worker too
 
 	/* 3️⃣  Gin router */
 	router := gin.New()
@@ -68,9 +91,14 @@ func main() {
 	// stop accepting new HTTP requests
 	_ = srv.Shutdown(ctx)
 
-	// stop crawler queue & wait for workers to finish
-	close(crawler.Jobs)
-	crawler.Wait()
+	// stop claiming new jobs & give running crawls until the deadline;
+	// anything unfinished goes back to the jobs table for the next start
+	stopScheduler()
+	workCtx, cancelWork := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelWork()
+	if err := crawler.Workers.Shutdown(workCtx); err != nil {
+		log.Println("crawls interrupted:", err)
+	}
 
 	// close DB connection pool
 	if sqlDB, err := database.DB.DB(); err == nil {
@@ -0,0 +1,65 @@
+// Command worker runs crawls from the shared job queue without serving
+// the API. Start any number of them next to one cmd/api (run that with
+// CRAWLER_WORKERS=-1 to leave all crawling to the workers); both sides
+// need CRAWLER_BROKER=db so progress and stop requests cross processes.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	// ── internal packages ─────────────────────────────
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/env"
+)
+
+func main() {
+	/* 1️⃣  Database (cmd/api owns the migrations) */
+	database.Init()
+	crawler.Init(crawler.Config{
+		UserAgent:       os.Getenv("CRAWLER_USER_AGENT"),
+		HostConcurrency: env.Int("CRAWLER_HOST_CONCURRENCY"),
+		HostInterval:    env.Duration("CRAWLER_HOST_INTERVAL"),
+		LinkWorkers:     env.Int("CRAWLER_LINK_WORKERS"),
+		MaxPageBytes:    int64(env.Int("CRAWLER_MAX_PAGE_BYTES")),
+
+		LongRedirectChain: env.Int("CRAWLER_LONG_REDIRECT_CHAIN"),
+		Workers:           env.Int("CRAWLER_WORKERS"), // default 2× CPU
+
+		AllowHosts: env.List("CRAWLER_ALLOW_HOSTS"), // e.g. "intranet.local,10.1.0.0/16"
+	})
+	broker := crawler.NewDBBroker(time.Second)
+	crawler.SetBroker(broker)
+
+	/* 2️⃣  Worker pool */
+	// pick up jobs left behind by a worker that died
+	if err := crawler.Recover(); err != nil {
+		log.Fatal("job recovery failed:", err)
+	}
+	crawler.Workers = crawler.NewPool(0)
+	log.Printf("worker started with %d crawlers", crawler.Workers.Size())
+
+	/* 3️⃣  Wait for SIGINT / SIGTERM */
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("shutdown signal received")
+
+	/* 4️⃣  Graceful shutdown: finish or hand back running crawls */
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := crawler.Workers.Shutdown(ctx); err != nil {
+		log.Println("crawls interrupted:", err)
+	}
+	broker.Close()
+
+	if sqlDB, err := database.DB.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	log.Println("worker exited cleanly")
+}
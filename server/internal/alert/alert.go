@@ -0,0 +1,160 @@
+// Package alert evaluates users' alert rules against finished crawls
+// and records the alerts they trip, with the offending links as
+// evidence.
+package alert
+
+import (
+	"slices"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"gorm.io/gorm"
+)
+
+// evidenceLimit caps the links stored per alert.
+const evidenceLimit = 50
+
+// metrics maps each rule metric to the links of a run it counts.
+var metrics = map[string]func(*gorm.DB) *gorm.DB{
+	"broken_links": func(q *gorm.DB) *gorm.DB {
+		return q.Where("check_status = ? AND http_status >= 400", "checked")
+	},
+	"internal_4xx": statusRange(true, 400, 499),
+	"internal_5xx": statusRange(true, 500, 599),
+	"external_4xx": statusRange(false, 400, 499),
+	"external_5xx": statusRange(false, 500, 599),
+	"failed_links": func(q *gorm.DB) *gorm.DB { // no response: dns, tls, timeout, …
+		return q.Where("check_status = ? AND error_kind <> ''", "checked")
+	},
+	"blocked_by_robots": func(q *gorm.DB) *gorm.DB { return q.Where("check_status = ?", "blocked_by_robots") },
+	"unchecked_links":   func(q *gorm.DB) *gorm.DB { return q.Where("check_status = ?", "unchecked") },
+}
+
+func statusRange(internal bool, lo, hi int) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		return q.Where("is_internal = ? AND http_status BETWEEN ? AND ?", internal, lo, hi)
+	}
+}
+
+// Ops are the comparisons a rule can use.
+var Ops = []string{">", ">=", "<", "<=", "==", "!="}
+
+// Metrics lists the metric names a rule can use, sorted.
+func Metrics() []string {
+	names := make([]string, 0, len(metrics))
+	for m := range metrics {
+		names = append(names, m)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Valid reports whether metric and op name a rule that can be evaluated.
+func Valid(metric, op string) bool {
+	_, ok := metrics[metric]
+	return ok && slices.Contains(Ops, op)
+}
+
+func compare(v int, op string, threshold int) bool {
+	switch op {
+	case ">":
+		return v > threshold
+	case ">=":
+		return v >= threshold
+	case "<":
+		return v < threshold
+	case "<=":
+		return v <= threshold
+	case "==":
+		return v == threshold
+	case "!=":
+		return v != threshold
+	}
+	return false
+}
+
+// Evaluate checks the rules of run's URL against its result. A rule that
+// trips opens an alert, or refreshes the URL's unresolved alert for that
+// rule. Only finished (done) runs are evaluated.
+func Evaluate(runID uint64) error {
+	var run models.CrawlRun
+	if err := database.DB.Select("id", "url_id", "status").First(&run, runID).Error; err != nil {
+		return err
+	}
+	if run.Status != "done" {
+		return nil
+	}
+	var u models.URL
+	if err := database.DB.Select("id", "user_id").First(&u, run.URLID).Error; err != nil {
+		return err
+	}
+
+	var rules []models.AlertRule
+	if err := database.DB.
+		Where("user_id = ? AND (url_id IS NULL OR url_id = ?)", u.UserID, u.ID).
+		Order("id").Find(&rules).Error; err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		links := func() *gorm.DB {
+			return metrics[r.Metric](database.DB.Model(&models.Link{}).Where("run_id = ?", run.ID))
+		}
+		var n int64
+		if err := links().Count(&n).Error; err != nil {
+			return err
+		}
+		if !compare(int(n), r.Op, r.Threshold) {
+			continue
+		}
+
+		var rows []models.Link
+		if err := links().Order("id").Limit(evidenceLimit).Find(&rows).Error; err != nil {
+			return err
+		}
+		if err := record(&r, &u, run.ID, int(n), rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record opens an alert for r on u, or refreshes the unresolved one.
+func record(r *models.AlertRule, u *models.URL, runID uint64, value int, links []models.Link) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var a models.Alert
+		res := tx.Where("rule_id = ? AND url_id = ? AND state <> ?", r.ID, u.ID, "resolved").
+			Limit(1).Find(&a)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected == 0 {
+			a = models.Alert{RuleID: r.ID, UserID: u.UserID, URLID: u.ID, RunID: runID,
+				Metric: r.Metric, Op: r.Op, Threshold: r.Threshold, Value: value, State: "open"}
+			if err := tx.Create(&a).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Model(&a).Updates(map[string]any{
+				"run_id": runID, "value": value, "updated_at": time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("alert_id = ?", a.ID).Delete(&models.AlertEvidence{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(links) == 0 {
+			return nil
+		}
+		ev := make([]models.AlertEvidence, len(links))
+		for i, l := range links {
+			ev[i] = models.AlertEvidence{AlertID: a.ID, Href: l.Href, HTTPStatus: l.HTTPStatus,
+				ErrorKind: l.ErrorKind, IsInternal: l.IsInternal}
+		}
+		return tx.Create(&ev).Error
+	})
+}
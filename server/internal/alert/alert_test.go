@@ -0,0 +1,115 @@
+package alert
+
+import (
+	"testing"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func crawl(t *testing.T, u *models.URL, statuses map[string]int, internal bool) uint64 {
+	t.Helper()
+	run := models.CrawlRun{URLID: u.ID, Status: "done"}
+	database.DB.Create(&run)
+	for href, st := range statuses {
+		database.DB.Create(&models.Link{URLID: u.ID, RunID: &run.ID, Href: href, HTTPStatus: &st,
+			IsInternal: internal, CheckStatus: "checked"})
+	}
+	if err := Evaluate(run.ID); err != nil {
+		t.Fatal(err)
+	}
+	return run.ID
+}
+
+func TestEvaluateOpensAndRefreshesAlerts(t *testing.T) {
+	test.InitInMemoryDB()
+
+	u := models.URL{OriginalURL: "https://example.com", UserID: 1}
+	other := models.URL{OriginalURL: "https://other.example", UserID: 1}
+	database.DB.Create(&u)
+	database.DB.Create(&other)
+	global := models.AlertRule{UserID: 1, Metric: "broken_links", Op: ">", Threshold: 0}
+	scoped := models.AlertRule{UserID: 1, URLID: &other.ID, Metric: "internal_5xx", Op: ">", Threshold: 0}
+	foreign := models.AlertRule{UserID: 2, Metric: "broken_links", Op: ">=", Threshold: 0}
+	database.DB.Create(&global)
+	database.DB.Create(&scoped)
+	database.DB.Create(&foreign)
+
+	// a clean crawl trips nothing
+	crawl(t, &u, map[string]int{"https://example.com/a": 200}, true)
+	var n int64
+	database.DB.Model(&models.Alert{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("%d alerts after a clean crawl", n)
+	}
+
+	// broken links open one alert with the links as evidence; the rule
+	// scoped to the other URL stays quiet
+	crawl(t, &u, map[string]int{"https://example.com/a": 404, "https://example.com/b": 503}, true)
+	var a models.Alert
+	database.DB.Preload("Evidence").Where("url_id = ?", u.ID).First(&a)
+	if a.RuleID != global.ID || a.Value != 2 || a.State != "open" || len(a.Evidence) != 2 {
+		t.Fatalf("alert = %+v", a)
+	}
+
+	// a later bad crawl refreshes the open alert instead of adding one
+	run := crawl(t, &u, map[string]int{"https://example.com/c": 500}, true)
+	database.DB.Model(&models.Alert{}).Count(&n)
+	database.DB.Preload("Evidence").First(&a, a.ID)
+	if n != 1 || a.RunID != run || a.Value != 1 || len(a.Evidence) != 1 || a.Evidence[0].Href != "https://example.com/c" {
+		t.Fatalf("after refresh: %d alerts, %+v", n, a)
+	}
+
+	// once resolved, the next bad crawl opens a new one
+	database.DB.Model(&a).Update("state", "resolved")
+	crawl(t, &u, map[string]int{"https://example.com/c": 500}, true)
+	database.DB.Model(&models.Alert{}).Where("state = ?", "open").Count(&n)
+	if n != 1 {
+		t.Fatalf("%d open alerts after resolve + bad crawl, want 1", n)
+	}
+
+	// internal 5xx on the other URL trips the global and the scoped rule
+	crawl(t, &other, map[string]int{"https://other.example/x": 502}, true)
+	database.DB.Model(&models.Alert{}).Where("url_id = ?", other.ID).Count(&n)
+	if n != 2 {
+		t.Fatalf("%d alerts for internal 5xx, want 2", n)
+	}
+}
+
+func TestEvaluateMetrics(t *testing.T) {
+	test.InitInMemoryDB()
+
+	u := models.URL{OriginalURL: "https://example.com", UserID: 1}
+	database.DB.Create(&u)
+	run := models.CrawlRun{URLID: u.ID, Status: "done"}
+	database.DB.Create(&run)
+	links := []models.Link{
+		{Href: "https://example.com/404", HTTPStatus: ptr(404), IsInternal: true, CheckStatus: "checked"},
+		{Href: "https://ext.example/500", HTTPStatus: ptr(500), CheckStatus: "checked"},
+		{Href: "https://down.example/", HTTPStatus: ptr(0), ErrorKind: "dns", CheckStatus: "checked"},
+		{Href: "https://example.com/private", CheckStatus: "blocked_by_robots"},
+		{Href: "https://example.com/slow", CheckStatus: "unchecked"},
+	}
+	for i := range links {
+		links[i].URLID, links[i].RunID = u.ID, &run.ID
+	}
+	database.DB.Create(&links)
+
+	want := map[string]int64{
+		"broken_links": 2, "internal_4xx": 1, "internal_5xx": 0, "external_4xx": 0,
+		"external_5xx": 1, "failed_links": 1, "blocked_by_robots": 1, "unchecked_links": 1,
+	}
+	if len(want) != len(Metrics()) {
+		t.Fatalf("metrics %v not all covered", Metrics())
+	}
+	for m, w := range want {
+		var n int64
+		metrics[m](database.DB.Model(&models.Link{}).Where("run_id = ?", run.ID)).Count(&n)
+		if n != w {
+			t.Errorf("%s = %d, want %d", m, n, w)
+		}
+	}
+}
+
+func ptr(v int) *int { return &v }
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/alert"
+	"github.com/zeewaqar/web-crawler/server/internal/api/repo"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"gorm.io/gorm"
+)
+
+/*──────────────── alert rules ────────────────*/
+
+type createAlertRuleRequest struct {
+	URLID     *uint64 `json:"url_id"` // omitted: every URL of the caller
+	Metric    string  `json:"metric" binding:"required"`
+	Op        string  `json:"op" binding:"required"`
+	Threshold int     `json:"threshold"`
+}
+
+// CreateAlertRule adds a rule evaluated after each finished crawl of the
+// given URL, or of all the caller's URLs.
+func CreateAlertRule(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	// 1️⃣  Validate
+	var req createAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric and op required"})
+		return
+	}
+	if !alert.Valid(req.Metric, req.Op) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule",
+			"metrics": alert.Metrics(), "ops": alert.Ops})
+		return
+	}
+	if req.URLID != nil {
+		_, err := repo.URL(uid, *req.URLID)
+		switch {
+		case errors.Is(err, repo.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "url not found"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+	}
+
+	// 2️⃣  Store
+	rule := models.AlertRule{UserID: uid, URLID: req.URLID, Metric: req.Metric, Op: req.Op, Threshold: req.Threshold}
+	if err := database.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// ListAlertRules returns the caller's rules; ?url_id= narrows them to
+// those that apply to that URL.
+func ListAlertRules(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	q := database.DB.Where("user_id = ?", uid)
+	if s := c.Query("url_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url_id"})
+			return
+		}
+		q = q.Where("url_id IS NULL OR url_id = ?", id)
+	}
+	rules := []models.AlertRule{}
+	if err := q.Order("id").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// DeleteAlertRule removes a rule. Its alerts are kept.
+func DeleteAlertRule(c *gin.Context) {
+	rule, ok := ownedRow(c, repo.AlertRule)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": rule.ID})
+}
+
+/*──────────────── alerts ────────────────*/
+
+// ListAlerts returns the caller's alerts with their evidence, newest
+// first. Filters: ?state=open|acknowledged|resolved and ?url_id=.
+func ListAlerts(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	// pagination params
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	var urlID *uint64
+	if s := c.Query("url_id"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url_id"})
+			return
+		}
+		urlID = &id
+	}
+	state := c.Query("state")
+
+	// filters shared by the page and the count
+	filter := func(q *gorm.DB) *gorm.DB {
+		q = q.Where("user_id = ?", uid)
+		if state != "" {
+			q = q.Where("state = ?", state)
+		}
+		if urlID != nil {
+			q = q.Where("url_id = ?", *urlID)
+		}
+		return q
+	}
+
+	alerts := []models.Alert{}
+	if err := database.DB.Scopes(filter).
+		Preload("Evidence").
+		Order("id DESC").
+		Limit(size).
+		Offset((page - 1) * size).
+		Find(&alerts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	var total int64
+	database.DB.Model(&models.Alert{}).Scopes(filter).Count(&total)
+
+	c.JSON(http.StatusOK, gin.H{"data": alerts, "total": total})
+}
+
+// AcknowledgeAlert marks an open alert as seen; 409 if it is not open.
+func AcknowledgeAlert(c *gin.Context) {
+	moveAlert(c, []string{"open"}, "acknowledged", "acknowledged_at")
+}
+
+// ResolveAlert closes an alert; the next crawl that trips its rule opens
+// a new one. 409 if it is already resolved.
+func ResolveAlert(c *gin.Context) {
+	moveAlert(c, []string{"open", "acknowledged"}, "resolved", "resolved_at")
+}
+
+// moveAlert moves the caller's alert named by :id from one of from to
+// state, stamping column.
+func moveAlert(c *gin.Context, from []string, state, column string) {
+	a, ok := ownedRow(c, repo.Alert)
+	if !ok {
+		return
+	}
+	res := database.DB.Model(a).
+		Where("state IN ?", from).
+		Updates(map[string]any{"state": state, column: time.Now()})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "alert is " + a.State})
+		return
+	}
+	database.DB.Preload("Evidence").First(a, a.ID)
+	c.JSON(http.StatusOK, a)
+}
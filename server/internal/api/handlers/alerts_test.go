@@ -0,0 +1,92 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api"
+	"github.com/zeewaqar/web-crawler/server/internal/auth"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestAlertRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	auth.Init("test-secret")
+
+	r := gin.New()
+	api.Register(r)
+	call := func(uid uint64, method, path, body string) *httptest.ResponseRecorder {
+		token, _ := auth.NewToken(uid)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	u := models.URL{OriginalURL: "https://example.com", UserID: 1}
+	database.DB.Create(&u)
+	urlID := strconv.FormatUint(u.ID, 10)
+
+	// rules
+	for body, want := range map[string]int{
+		`{"metric":"broken_links","op":">","threshold":0}`:                        201,
+		`{"url_id":` + urlID + `,"metric":"internal_5xx","op":">","threshold":0}`: 201,
+		`{"metric":"nope","op":">"}`:                                              400,
+		`{"metric":"broken_links","op":"=~"}`:                                     400,
+		`{"url_id":999,"metric":"broken_links","op":">"}`:                         404,
+	} {
+		if w := call(1, "POST", "/api/v1/alert-rules", body); w.Code != want {
+			t.Errorf("POST rule %s: got %d want %d: %s", body, w.Code, want, w.Body)
+		}
+	}
+	if w := call(2, "POST", "/api/v1/alert-rules", `{"url_id":`+urlID+`,"metric":"broken_links","op":">"}`); w.Code != 404 {
+		t.Errorf("rule on another user's URL: got %d want 404", w.Code)
+	}
+	if w := call(2, "GET", "/api/v1/alert-rules", ""); strings.Contains(w.Body.String(), "broken_links") {
+		t.Errorf("rules leaked to another user: %s", w.Body)
+	}
+
+	// alerts: open → acknowledged → resolved
+	status := 404
+	a := models.Alert{RuleID: 1, UserID: 1, URLID: u.ID, RunID: 1, Metric: "broken_links", Op: ">", Value: 1, State: "open",
+		Evidence: []models.AlertEvidence{{Href: "https://example.com/gone", HTTPStatus: &status, IsInternal: true}}}
+	database.DB.Create(&a)
+	id := strconv.FormatUint(a.ID, 10)
+
+	w := call(1, "GET", "/api/v1/alerts?state=open", "")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "https://example.com/gone") || !strings.Contains(w.Body.String(), `"total":1`) {
+		t.Fatalf("list: %d %s", w.Code, w.Body)
+	}
+	if w := call(2, "POST", "/api/v1/alerts/"+id+"/acknowledge", ""); w.Code != 404 {
+		t.Errorf("acknowledge as intruder: got %d want 404", w.Code)
+	}
+
+	steps := []struct {
+		action string
+		want   int
+		state  string
+	}{
+		{"acknowledge", 200, "acknowledged"},
+		{"acknowledge", 409, "acknowledged"},
+		{"resolve", 200, "resolved"},
+		{"resolve", 409, "resolved"},
+	}
+	for _, s := range steps {
+		w := call(1, "POST", "/api/v1/alerts/"+id+"/"+s.action, "")
+		database.DB.First(&a, a.ID)
+		if w.Code != s.want || a.State != s.state {
+			t.Fatalf("%s: got %d/%s want %d/%s: %s", s.action, w.Code, a.State, s.want, s.state, w.Body)
+		}
+	}
+	if a.AcknowledgedAt == nil || a.ResolvedAt == nil {
+		t.Fatalf("timestamps not set: %+v", a)
+	}
+}
@@ -12,14 +12,38 @@ type bulkStopPayload struct {
 	IDs []uint64 `json:"ids"`
 }
 
+// BulkStop stops every listed crawl and reports, per id, whether it was
+// cancelled, is stopping, or was not running.
 func BulkStop(c *gin.Context) {
 	var body bulkStopPayload
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.Status(http.StatusBadRequest)
 		return
 	}
-	for _, id := range body.IDs {
-		crawler.Cancel(id)
+
+	uidAny, _ := c.Get("uid")
+	stopURLs(uidAny.(uint64), body.IDs).write(c)
+}
+
+// stopURLs stops the crawls of each of ids that uid owns.
+func stopURLs(uid uint64, ids []uint64) reply {
+	// only the caller's own URLs are stopped
+	ids, r := ownedBy(uid, ids)
+	if r != nil {
+		return *r
+	}
+
+	results := make(map[uint64]crawler.StopResult, len(ids))
+	stopped := 0
+	for _, id := range ids {
+		res, err := crawler.Cancel(id)
+		if err != nil {
+			return failed(http.StatusInternalServerError, "db")
+		}
+		results[id] = res
+		if res != crawler.StopNotRunning {
+			stopped++
+		}
 	}
-	c.Status(http.StatusAccepted)
+	return reply{status: http.StatusOK, body: gin.H{"stopped": stopped, "results": results}}
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+)
+
+// how long clients are told to back off when a crawl cannot be queued
+const (
+	retryAfterFull        = 30 * time.Second
+	retryAfterUnavailable = 5 * time.Second
+)
+
+// reply is the outcome of a crawl command. The REST handlers write it as
+// the HTTP response; the WebSocket API sends it as a reply message.
+type reply struct {
+	status     int
+	body       gin.H
+	retryAfter time.Duration // > 0 when the queue turned the command away
+}
+
+func (r reply) write(c *gin.Context) {
+	if r.retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(r.retryAfter.Seconds())))
+	}
+	c.JSON(r.status, r.body)
+}
+
+func failed(status int, msg string) reply {
+	return reply{status: status, body: gin.H{"error": msg}}
+}
+
+// enqueueFailed answers a failed crawler.Enqueue: 429 when the queue is
+// full, 503 when the queue store is unreachable. Both set Retry-After.
+func enqueueFailed(err error) reply {
+	status, wait, msg := http.StatusServiceUnavailable, retryAfterUnavailable, "queue unavailable"
+	if errors.Is(err, crawler.ErrQueueFull) {
+		status, wait, msg = http.StatusTooManyRequests, retryAfterFull, "crawl queue is full, try again later"
+	}
+
+	depth, _ := crawler.QueueDepth()
+	return reply{status: status, body: gin.H{"error": msg, "queue_depth": depth}, retryAfter: wait}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+type jobRow struct {
+	ID          uint64           `json:"id"`
+	URLID       uint64           `json:"url_id"`
+	OriginalURL string           `json:"original_url"`
+	State       string           `json:"state"`
+	Priority    crawler.Priority `json:"priority"`
+	Attempts    int              `json:"attempts"`
+	ClaimedAt   *time.Time       `json:"claimed_at"`
+	LastError   *string          `json:"last_error"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// ListJobs returns the caller's crawl jobs, by default those still
+// queued or running. ?state=done,failed selects others.
+func ListJobs(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	states := []string{"queued", "leased"}
+	if s := strings.TrimSpace(c.Query("state")); s != "" {
+		states = strings.Split(s, ",")
+	}
+
+	var rows []jobRow
+	if err := database.DB.Model(&models.Job{}).
+		Select("jobs.id, jobs.url_id, urls.original_url, jobs.state, jobs.priority, "+
+			"jobs.attempts, jobs.claimed_at, jobs.last_error, jobs.created_at").
+		Joins("JOIN urls ON urls.id = jobs.url_id").
+		Where("jobs.user_id = ? AND jobs.state IN ?", uid, states).
+		Order("jobs.priority DESC, jobs.id").
+		Limit(500).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api/repo"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// ownedURL loads the URL named by :id for the calling user. On failure
+// it writes 400 (bad id), 404 (missing or someone else's) or 500 and
+// returns false. cols limits the loaded columns, as in repo.URL.
+func ownedURL(c *gin.Context, cols ...string) (*models.URL, bool) {
+	return ownedRow(c, func(uid, id uint64) (*models.URL, error) {
+		return repo.URL(uid, id, cols...)
+	})
+}
+
+// ownedIDs narrows ids to the caller's URLs, writing 404 when none are
+// left or 500 on a database error.
+func ownedIDs(c *gin.Context, ids []uint64) ([]uint64, bool) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	owned, r := ownedBy(uid, ids)
+	if r != nil {
+		r.write(c)
+		return nil, false
+	}
+	return owned, true
+}
+
+// ownedBy narrows ids to uid's URLs. The reply is set (404 or 500) when
+// none are left or the lookup failed.
+func ownedBy(uid uint64, ids []uint64) ([]uint64, *reply) {
+	owned, err := repo.OwnedURLIDs(uid, ids)
+	if err != nil {
+		r := failed(http.StatusInternalServerError, "db")
+		return nil, &r
+	}
+	if len(owned) == 0 {
+		r := failed(http.StatusNotFound, "not found")
+		return nil, &r
+	}
+	return owned, nil
+}
+
+// ownedRow loads the caller's row named by :id with load (one of the
+// repo lookups), writing 400, 404 or 500 and returning false when there
+// is none.
+func ownedRow[T any](c *gin.Context, load func(uid, id uint64) (*T, error)) (*T, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil, false
+	}
+
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	row, err := load(uid, id)
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return nil, false
+	}
+	return row, true
+}
@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api"
+	"github.com/zeewaqar/web-crawler/server/internal/auth"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+// TestURLRoutesHideOtherUsersURLs calls every /urls route as a user who
+// does not own the URL and expects it to behave as if the URL did not exist.
+func TestURLRoutesHideOtherUsersURLs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	auth.Init("test-secret")
+
+	const owner, intruder = 1, 2
+	u := models.URL{OriginalURL: "https://owned.example", UserID: owner, CrawlStatus: "running"}
+	database.DB.Create(&u)
+	run := models.CrawlRun{URLID: u.ID, Status: "done"}
+	database.DB.Create(&run)
+	database.DB.Create(&models.Job{URLID: u.ID, UserID: owner, State: "leased"})
+
+	id := strconv.FormatUint(u.ID, 10)
+	ids := `{"ids":[` + id + `]}`
+	cases := []struct {
+		method, route, path, body string
+		want                      int
+	}{
+		{"GET", "/api/v1/urls/:id", "/api/v1/urls/" + id, "", 404},
+		{"GET", "/api/v1/urls/:id/stream", "/api/v1/urls/" + id + "/stream", "", 404},
+		{"GET", "/api/v1/urls/stream", "/api/v1/urls/stream?ids=" + id, "", 404},
+		{"GET", "/api/v1/urls/:id/runs", "/api/v1/urls/" + id + "/runs", "", 404},
+		{"GET", "/api/v1/urls/:id/runs/:runId", "/api/v1/urls/" + id + "/runs/" + strconv.FormatUint(run.ID, 10), "", 404},
+		{"GET", "/api/v1/urls/:id/diff", "/api/v1/urls/" + id + "/diff", "", 404},
+		{"PUT", "/api/v1/urls/:id/stop", "/api/v1/urls/" + id + "/stop", "", 404},
+		{"PUT", "/api/v1/urls/:id/schedule", "/api/v1/urls/" + id + "/schedule", `{"schedule":"@daily"}`, 404},
+		{"POST", "/api/v1/urls/:id/schedule/pause", "/api/v1/urls/" + id + "/schedule/pause", "", 404},
+		{"POST", "/api/v1/urls/:id/schedule/resume", "/api/v1/urls/" + id + "/schedule/resume", "", 404},
+		{"DELETE", "/api/v1/urls/:id/schedule", "/api/v1/urls/" + id + "/schedule", "", 404},
+		{"POST", "/api/v1/urls/bulk/restart", "/api/v1/urls/bulk/restart", ids, 404},
+		{"POST", "/api/v1/urls/bulk/stop", "/api/v1/urls/bulk/stop", ids, 404},
+		{"DELETE", "/api/v1/urls", "/api/v1/urls", ids, 404},
+		// not ID-based: the intruder only ever sees and creates their own rows
+		{"GET", "/api/v1/urls", "/api/v1/urls", "", 200},
+		{"POST", "/api/v1/urls", "/api/v1/urls", `{"url":"https://owned.example"}`, 202},
+	}
+
+	r := gin.New()
+	api.Register(r)
+	token, _ := auth.NewToken(intruder)
+
+	covered := map[string]bool{}
+	for _, tc := range cases {
+		covered[tc.method+" "+tc.route] = true
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.want {
+			t.Errorf("%s %s: got %d want %d: %s", tc.method, tc.path, w.Code, tc.want, w.Body)
+		}
+		if tc.route == "/api/v1/urls" && tc.method == "GET" && strings.Contains(w.Body.String(), "owned.example") {
+			t.Errorf("GET /urls leaked another user's URL: %s", w.Body)
+		}
+	}
+
+	// every /urls route registered must be in the table above
+	for _, rt := range r.Routes() {
+		if strings.HasPrefix(rt.Path, "/api/v1/urls") && !covered[rt.Method+" "+rt.Path] {
+			t.Errorf("route %s %s has no cross-user test", rt.Method, rt.Path)
+		}
+	}
+
+	// nothing of the owner's changed
+	var after models.URL
+	database.DB.First(&after, u.ID)
+	var job models.Job
+	database.DB.Where("url_id = ?", u.ID).First(&job)
+	if after.CrawlStatus != "running" || after.Schedule != nil || job.CancelRequestedAt != nil {
+		t.Fatalf("owner's URL was modified: %+v, job %+v", after, job)
+	}
+}
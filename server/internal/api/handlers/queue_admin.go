@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+)
+
+/*──────── admin: queue inspection & control ────────*/
+
+// GetQueue reports pending work per user, running crawls and worker usage.
+func GetQueue(c *gin.Context) {
+	s, err := crawler.Status()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// PauseQueue stops workers from starting new crawls.
+func PauseQueue(c *gin.Context) {
+	if err := crawler.PauseQueue(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"paused": true})
+}
+
+// ResumeQueue lets workers start new crawls again.
+func ResumeQueue(c *gin.Context) {
+	if err := crawler.ResumeQueue(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"paused": false})
+}
+
+// DrainUserQueue drops the pending jobs of one user.
+func DrainUserQueue(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	n, err := crawler.DrainUser(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"drained": n})
+}
+
+type resizePayload struct {
+	Size *int `json:"size" binding:"required"`
+}
+
+// ListWorkers reports the state of every worker in the pool.
+func ListWorkers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"size":    crawler.Workers.Size(),
+		"workers": crawler.Workers.States(),
+	})
+}
+
+// ResizeWorkers grows or shrinks the worker pool at runtime.
+func ResizeWorkers(c *gin.Context) {
+	var body resizePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size required"})
+		return
+	}
+	if *body.Size < 0 || *body.Size > crawler.MaxWorkers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be 0-" + strconv.Itoa(crawler.MaxWorkers)})
+		return
+	}
+
+	if err := crawler.Workers.Resize(*body.Size); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	ListWorkers(c)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+	"github.com/zeewaqar/web-crawler/server/internal/api/repo"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+)
+
+/*──────────────── WebSocket API ────────────────
+ * GET /api/v1/ws upgrades once RequireJWT has accepted the token (the
+ * ?token= form, as browsers cannot set headers on an upgrade). Clients
+ * send one JSON command per message:
+ *
+ *	{"id":"1","op":"subscribe","url_ids":[3,4]}
+ *	{"id":"2","op":"unsubscribe","url_ids":[3]}
+ *	{"id":"3","op":"stop","url_ids":[4]}
+ *	{"id":"4","op":"restart","url_ids":[4],"priority":"interactive"}
+ *	{"id":"5","op":"enqueue","url":"https://…","max_depth":1}
+ *
+ * Each gets a reply {"type":"reply","reply_to":…,"status":…,"body":…} with
+ * the status and body the matching REST endpoint would answer with.
+ * Events of subscribed URLs arrive as crawler.Event JSON, as in the SSE
+ * streams; subscribe first sends a status event per URL and enqueue
+ * subscribes to the URL it creates. Every command is checked against the
+ * caller's URLs, so a foreign id behaves like a missing one.
+ *───────────────────────────────────────────────*/
+
+const (
+	wsReadLimit = 64 << 10         // largest command accepted
+	wsWriteWait = 10 * time.Second // per message, pings included
+)
+
+var upgrader = websocket.Upgrader{
+	// the socket is authenticated by the JWT, never by a cookie, so a
+	// foreign page cannot open one on the user's behalf
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+type wsCommand struct {
+	ID       string   `json:"id"` // echoed as the reply's reply_to
+	Op       string   `json:"op"` // subscribe | unsubscribe | stop | restart | enqueue
+	URLIDs   []uint64 `json:"url_ids"`
+	Priority string   `json:"priority"` // restart only; enqueue reads createURLRequest
+}
+
+type wsReply struct {
+	Type       string `json:"type"`               // always "reply"
+	ReplyTo    string `json:"reply_to,omitempty"` // the command's id
+	Status     int    `json:"status"`
+	Body       gin.H  `json:"body"`
+	RetryAfter int    `json:"retry_after,omitempty"` // seconds, as the Retry-After header
+}
+
+func replyTo(id string, r reply) wsReply {
+	return wsReply{Type: "reply", ReplyTo: id, Status: r.status, Body: r.body,
+		RetryAfter: int(r.retryAfter.Seconds())}
+}
+
+// socket is the state of one connection.
+type socket struct {
+	uid uint64
+	ctx context.Context // the upgrade request's; lasts as long as the connection
+
+	mu         sync.Mutex
+	subscribed map[uint64]bool
+}
+
+func CrawlSocket(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	s := &socket{uid: uidAny.(uint64), ctx: c.Request.Context(), subscribed: map[uint64]bool{}}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade has answered the request
+	}
+	defer conn.Close()
+
+	events, unsubscribe := crawler.SubscribeUser(s.uid)
+	defer unsubscribe()
+
+	// one writer owns the connection; the reader hands it replies
+	out := make(chan any, 16)
+	readerDone, writerDone := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		s.write(conn, events, out, readerDone)
+	}()
+	defer func() {
+		close(readerDone)
+		<-writerDone
+	}()
+
+	conn.SetReadLimit(wsReadLimit)
+	alive := func() { conn.SetReadDeadline(time.Now().Add(2 * heartbeat)) }
+	alive()
+	conn.SetPongHandler(func(string) error { alive(); return nil })
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		alive()
+		for _, m := range s.handle(raw) {
+			select {
+			case out <- m:
+			case <-writerDone:
+				return
+			}
+		}
+	}
+}
+
+// write sends replies and the events of subscribed URLs, and pings the
+// client every heartbeat, until stop closes or the client is gone.
+func (s *socket) write(conn *websocket.Conn, events crawler.EventCh, out <-chan any, stop <-chan struct{}) {
+	defer conn.Close() // ends the reader if the client went away
+
+	ping := time.NewTicker(heartbeat)
+	defer ping.Stop()
+	for {
+		var msg any
+		select {
+		case e := <-events:
+			if !s.wants(e.URLID) {
+				continue
+			}
+			msg = e
+		case msg = <-out:
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)) != nil {
+				return
+			}
+			continue
+		case <-stop:
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		if conn.WriteJSON(msg) != nil {
+			return
+		}
+	}
+}
+
+func (s *socket) wants(urlID uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribed[urlID]
+}
+
+// handle runs one command and returns the messages to send back: its
+// reply, followed by any snapshot events.
+func (s *socket) handle(raw []byte) []any {
+	var cmd wsCommand
+	if err := json.Unmarshal(raw, &cmd); err != nil {
+		return []any{replyTo("", failed(http.StatusBadRequest, "invalid message"))}
+	}
+
+	switch cmd.Op {
+	case "subscribe":
+		return s.subscribe(cmd)
+	case "unsubscribe":
+		s.mu.Lock()
+		for _, id := range cmd.URLIDs {
+			delete(s.subscribed, id)
+		}
+		s.mu.Unlock()
+		return []any{replyTo(cmd.ID, s.subscriptions())}
+	case "stop":
+		return []any{replyTo(cmd.ID, stopURLs(s.uid, cmd.URLIDs))}
+	case "restart":
+		if len(cmd.URLIDs) == 0 {
+			return []any{replyTo(cmd.ID, failed(http.StatusBadRequest, "url_ids required"))}
+		}
+		return []any{replyTo(cmd.ID, restartURLs(s.uid, cmd.URLIDs, cmd.Priority))}
+	case "enqueue":
+		var req createURLRequest
+		if json.Unmarshal(raw, &req) != nil || binding.Validator.ValidateStruct(&req) != nil {
+			return []any{replyTo(cmd.ID, failed(http.StatusBadRequest, "invalid body"))}
+		}
+		r := createURL(s.ctx, s.uid, req)
+		if id, ok := r.body["id"].(uint64); ok {
+			s.mu.Lock()
+			s.subscribed[id] = true
+			s.mu.Unlock()
+		}
+		return []any{replyTo(cmd.ID, r)}
+	default:
+		return []any{replyTo(cmd.ID, failed(http.StatusBadRequest, "unknown op"))}
+	}
+}
+
+// subscribe adds the caller's URLs among cmd.URLIDs and snapshots their
+// status.
+func (s *socket) subscribe(cmd wsCommand) []any {
+	ids, r := ownedBy(s.uid, cmd.URLIDs)
+	if r != nil {
+		return []any{replyTo(cmd.ID, *r)}
+	}
+	urls, err := repo.Statuses(s.uid, ids)
+	if err != nil {
+		return []any{replyTo(cmd.ID, failed(http.StatusInternalServerError, "db"))}
+	}
+
+	s.mu.Lock()
+	for _, id := range ids {
+		s.subscribed[id] = true
+	}
+	s.mu.Unlock()
+
+	msgs := []any{replyTo(cmd.ID, s.subscriptions())}
+	for _, u := range urls {
+		msgs = append(msgs, statusEvent(&u))
+	}
+	return msgs
+}
+
+func (s *socket) subscriptions() reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]uint64, 0, len(s.subscribed))
+	for id := range s.subscribed {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return reply{status: http.StatusOK, body: gin.H{"subscribed": ids}}
+}
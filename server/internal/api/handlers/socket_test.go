@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/zeewaqar/web-crawler/server/internal/api"
+	"github.com/zeewaqar/web-crawler/server/internal/auth"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+type wsMessage struct {
+	Type    string         `json:"type"`
+	ReplyTo string         `json:"reply_to"`
+	URLID   uint64         `json:"url_id"`
+	Status  any            `json:"status"` // reply: HTTP status; status event: crawl status
+	Body    map[string]any `json:"body"`
+}
+
+func TestCrawlSocket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	auth.Init("test-secret")
+
+	theirs := models.URL{OriginalURL: "https://theirs.example", UserID: 2, CrawlStatus: "queued"}
+	database.DB.Create(&theirs)
+	database.DB.Create(&models.Job{URLID: theirs.ID, UserID: 2, State: "queued"})
+
+	r := gin.New()
+	api.Register(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	base := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/ws"
+
+	// no token, no upgrade
+	if _, resp, err := websocket.DefaultDialer.Dial(base, nil); err == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated dial: %v", err)
+	}
+
+	token, _ := auth.NewToken(1)
+	conn, _, err := websocket.DefaultDialer.Dial(base+"?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var seen []wsMessage // events received while waiting for replies
+	send := func(cmd string) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(cmd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replyTo := func(id string) wsMessage {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var m wsMessage
+			if err := conn.ReadJSON(&m); err != nil {
+				t.Fatalf("waiting for reply %s: %v", id, err)
+			}
+			if m.Type == "reply" && m.ReplyTo == id {
+				return m
+			}
+			seen = append(seen, m)
+		}
+	}
+	idOf := func(u uint64) string { return strconv.FormatUint(u, 10) }
+
+	// someone else's URL looks missing, for reading and for control
+	send(`{"id":"1","op":"subscribe","url_ids":[` + idOf(theirs.ID) + `]}`)
+	if m := replyTo("1"); m.Status != float64(404) {
+		t.Fatalf("subscribe to foreign URL: %+v", m)
+	}
+	send(`{"id":"2","op":"stop","url_ids":[` + idOf(theirs.ID) + `]}`)
+	if m := replyTo("2"); m.Status != float64(404) {
+		t.Fatalf("stop foreign URL: %+v", m)
+	}
+
+	// enqueue creates, queues and subscribes
+	send(`{"id":"3","op":"enqueue","url":"https://mine.example"}`)
+	m := replyTo("3")
+	if m.Status != float64(202) {
+		t.Fatalf("enqueue: %+v", m)
+	}
+	mine := uint64(m.Body["id"].(float64))
+
+	send(`{"id":"4","op":"stop","url_ids":[` + idOf(mine) + `]}`)
+	if m := replyTo("4"); m.Status != float64(200) || m.Body["stopped"] != float64(1) {
+		t.Fatalf("stop: %+v", m)
+	}
+	send(`{"id":"5","op":"bogus"}`) // by now the stop's status event has been sent
+	if m := replyTo("5"); m.Status != float64(400) {
+		t.Fatalf("unknown op: %+v", m)
+	}
+	var cancelled bool
+	for _, e := range seen {
+		if e.URLID == theirs.ID {
+			t.Fatalf("got an event of another user's URL: %+v", e)
+		}
+		cancelled = cancelled || (e.URLID == mine && e.Type == "status" && e.Status == "cancelled")
+	}
+	if !cancelled {
+		t.Fatalf("no cancelled status event for the stopped URL in %+v", seen)
+	}
+
+	var job models.Job
+	database.DB.Where("url_id = ?", theirs.ID).First(&job)
+	if job.State != "queued" {
+		t.Fatalf("foreign job was touched: %+v", job)
+	}
+}
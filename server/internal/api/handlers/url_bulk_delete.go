@@ -20,16 +20,18 @@ func BulkDelete(c *gin.Context) {
 		return
 	}
 
-	uidAny, _ := c.Get("uid")
-	uid := uidAny.(uint64)
+	// only the caller's own URLs are deleted
+	ids, ok := ownedIDs(c, body.IDs)
+	if !ok {
+		return
+	}
 
 	if err := database.DB.
-		Where("user_id = ?", uid).
-		Where("id IN ?", body.IDs).
+		Where("id IN ?", ids).
 		Delete(&models.URL{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"deleted": len(body.IDs)})
+	c.JSON(http.StatusOK, gin.H{"deleted": len(ids)})
 }
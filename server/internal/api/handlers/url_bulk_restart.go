@@ -11,7 +11,8 @@ import (
 
 // rename to avoid collision
 type bulkRestartPayload struct {
-	IDs []uint64 `json:"ids" binding:"required"`
+	IDs      []uint64 `json:"ids" binding:"required"`
+	Priority string   `json:"priority"` // bulk (default) | interactive | scheduled
 }
 
 func BulkRestart(c *gin.Context) {
@@ -22,24 +23,43 @@ func BulkRestart(c *gin.Context) {
 	}
 
 	uidAny, _ := c.Get("uid")
-	uid := uidAny.(uint64)
+	restartURLs(uidAny.(uint64), body.IDs, body.Priority).write(c)
+}
 
-	if err := database.DB.Model(&models.URL{}).
-		Where("user_id = ?", uid).
-		Where("id IN ?", body.IDs).
-		Updates(map[string]any{
-			"crawl_status":   "queued",
-			"internal_links": 0, "external_links": 0, "broken_links": 0,
-			"h1": 0, "h2": 0, "h3": 0,
-			"has_login": false,
-		}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
-		return
+// restartURLs queues a new crawl of each of ids that uid owns, at
+// priority (default bulk).
+func restartURLs(uid uint64, ids []uint64, priority string) reply {
+	prio, err := crawler.ParsePriority(priority, crawler.PriorityBulk)
+	if err != nil {
+		return failed(http.StatusBadRequest, err.Error())
 	}
 
-	for _, id := range body.IDs {
-		crawler.Jobs <- id
+	// only the caller's own URLs are restarted
+	ids, r := ownedBy(uid, ids)
+	if r != nil {
+		return *r
 	}
 
-	c.JSON(http.StatusAccepted, gin.H{"restarted": len(body.IDs)})
+	tickets, err := crawler.Enqueue(prio, ids...)
+	if err != nil {
+		return enqueueFailed(err)
+	}
+
+	// clear the old summary while waiting; a crawl that already started
+	// has reset it itself
+	database.DB.Model(&models.URL{}).
+		Where("id IN ? AND crawl_status = ?", ids, "queued").
+		Updates(map[string]any{
+			"internal_links": 0, "external_links": 0, "broken_links": 0,
+			"blocked_by_robots": 0, "unchecked_links": 0, "redirected_links": 0,
+			"final_url": nil, "redirect_loop": false, "h1": 0, "h2": 0, "h3": 0,
+			"has_login": false,
+		})
+
+	depth, _ := crawler.QueueDepth()
+	return reply{status: http.StatusAccepted, body: gin.H{
+		"restarted":   len(tickets),
+		"queue_depth": depth,
+		"jobs":        tickets,
+	}}
 }
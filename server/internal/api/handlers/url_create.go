@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"strings"
@@ -9,11 +10,17 @@ import (
 	"github.com/zeewaqar/web-crawler/server/internal/crawler"
 	"github.com/zeewaqar/web-crawler/server/internal/database"
 	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
 )
 
 // payload for bulk endpoints
 type createURLRequest struct {
-	URL string `json:"url" binding:"required"`
+	URL             string `json:"url"              binding:"required"`
+	MaxDepth        int    `json:"max_depth"        binding:"min=0,max=5"`     // 0 = root page only
+	MaxPages        int    `json:"max_pages"        binding:"min=0,max=500"`   // 0 = default (1)
+	HostConcurrency int    `json:"host_concurrency" binding:"min=0,max=16"`    // 0 = server default
+	HostIntervalMs  int    `json:"host_interval_ms" binding:"min=0,max=60000"` // 0 = server default
+	Priority        string `json:"priority"`                                   // interactive (default) | bulk | scheduled
 }
 
 func CreateURL(c *gin.Context) {
@@ -24,36 +31,59 @@ func CreateURL(c *gin.Context) {
 		return
 	}
 
+	// extract user ID from context
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	createURL(c.Request.Context(), uid, req).write(c)
+}
+
+// createURL stores req's URL for uid and queues its first crawl, unless
+// uid already has that URL.
+func createURL(ctx context.Context, uid uint64, req createURLRequest) reply {
+	prio, err := crawler.ParsePriority(req.Priority, crawler.PriorityInteractive)
+	if err != nil {
+		return failed(http.StatusBadRequest, err.Error())
+	}
+
 	raw := strings.TrimSpace(req.URL)
 	parsed, err := url.Parse(raw)
 	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "must be http or https"})
-		return
+		return failed(http.StatusBadRequest, "must be http or https")
+	}
+	if err := netguard.CheckHost(ctx, parsed.Hostname()); err != nil {
+		return failed(http.StatusBadRequest, "private or internal address not allowed")
 	}
-
-	// extract user ID from context
-	uidAny, _ := c.Get("uid")
-	uid := uidAny.(uint64)
 
 	// 2️⃣ upsert-or-return existing row
 	u := models.URL{
-		OriginalURL: raw,
-		CrawlStatus: "queued",
-		UserID:      uid,
+		OriginalURL:     raw,
+		CrawlStatus:     "queued",
+		UserID:          uid,
+		MaxDepth:        req.MaxDepth,
+		MaxPages:        max(req.MaxPages, 1),
+		HostConcurrency: req.HostConcurrency,
+		HostIntervalMs:  req.HostIntervalMs,
 	}
 
 	result := database.DB.
 		Where("original_url = ? AND user_id = ?", raw, uid).
 		FirstOrCreate(&u)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
-		return
+		return failed(http.StatusInternalServerError, "db error")
 	}
 
-	// 3️⃣ enqueue only if newly inserted
+	// 3️⃣ enqueue only if newly inserted; a rejected URL is not kept
+	resp := gin.H{"id": u.ID}
 	if result.RowsAffected == 1 {
-		crawler.Jobs <- u.ID
+		tickets, err := crawler.Enqueue(prio, u.ID)
+		if err != nil {
+			database.DB.Delete(&u)
+			return enqueueFailed(err)
+		}
+		resp["position"] = tickets[0].Position
 	}
+	resp["queue_depth"], _ = crawler.QueueDepth()
 
-	c.JSON(http.StatusAccepted, gin.H{"id": u.ID})
+	return reply{status: http.StatusAccepted, body: resp}
 }
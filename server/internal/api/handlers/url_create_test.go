@@ -0,0 +1,71 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api/handlers"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func createURL(uid uint64, raw string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/urls", strings.NewReader(`{"url":"`+raw+`"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("uid", uid)
+	handlers.CreateURL(c)
+	return w
+}
+
+func TestCreateURLBackpressure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	defer crawler.Init(crawler.Config{})
+	crawler.Init(crawler.Config{QueueCapacity: 1})
+
+	w := createURL(1, "https://a.example")
+	var body struct {
+		ID         uint64
+		Position   int64
+		QueueDepth int64 `json:"queue_depth"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != 202 {
+		t.Fatalf("got %d: %s", w.Code, w.Body)
+	}
+	if body.Position != 1 || body.QueueDepth != 1 {
+		t.Fatalf("position %d, depth %d; want 1, 1", body.Position, body.QueueDepth)
+	}
+
+	w = createURL(1, "https://b.example")
+	if w.Code != 429 || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("got %d (Retry-After %q); want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	var n int64
+	database.DB.Model(&models.URL{}).Count(&n)
+	if n != 1 {
+		t.Fatalf("%d URLs stored; rejected URL should not be kept", n)
+	}
+}
+
+func TestCreateURLRejectsInternalAddresses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+
+	for _, raw := range []string{"http://127.0.0.1:8080/", "http://169.254.169.254/latest/meta-data/", "http://[::1]/", "http://localhost/"} {
+		if w := createURL(1, raw); w.Code != 400 {
+			t.Errorf("%s: got %d; want 400", raw, w.Code)
+		}
+	}
+	var n int64
+	database.DB.Model(&models.URL{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("%d URLs stored; want none", n)
+	}
+}
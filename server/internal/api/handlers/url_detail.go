@@ -2,33 +2,31 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zeewaqar/web-crawler/server/internal/database"
 	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"gorm.io/gorm"
 )
 
 func GetURLDetail(c *gin.Context) {
-	// parse URL ID
-	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+	// only fetch if URL belongs to this user
+	urlRec, ok := ownedURL(c)
+	if !ok {
 		return
 	}
 
-	// get user ID
-	uidAny, _ := c.Get("uid")
-	uid := uidAny.(uint64)
-
-	// only fetch if URL belongs to this user
-	var urlRec models.URL
-	if err := database.DB.
-		Preload("Links").
-		Where("id = ? AND user_id = ?", id, uid).
-		First(&urlRec).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-		return
+	// links, pages and redirects of the latest run
+	if urlRec.LatestRunID != nil {
+		contents, err := loadRunContents(*urlRec.LatestRunID, c.Query("error_kind"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+			return
+		}
+		urlRec.Links = contents.Links
+		urlRec.Pages = contents.Pages
+		urlRec.Redirects = contents.Redirects
 	}
 
 	// ensure non‐nil slice for JSON
@@ -38,3 +36,74 @@ func GetURLDetail(c *gin.Context) {
 
 	c.JSON(http.StatusOK, urlRec)
 }
+
+// runContents is everything stored for one crawl run.
+type runContents struct {
+	Links     []models.Link
+	Pages     []models.Page // as a tree
+	Redirects []models.Redirect
+}
+
+// loadRunContents loads the links (with their redirect chains), page tree
+// and root redirect chain of a run. errorKinds, a comma separated list
+// like "dns,timeout", optionally narrows the links.
+func loadRunContents(runID uint64, errorKinds string) (*runContents, error) {
+	var out runContents
+
+	linkTx := database.DB.
+		Preload("Redirects", func(db *gorm.DB) *gorm.DB { return db.Order("hop") }).
+		Where("run_id = ?", runID).
+		Order("id")
+	if errorKinds != "" {
+		linkTx = linkTx.Where("error_kind IN ?", strings.Split(errorKinds, ","))
+	}
+	if err := linkTx.Find(&out.Links).Error; err != nil {
+		return nil, err
+	}
+
+	var pages []models.Page
+	if err := database.DB.
+		Where("run_id = ?", runID).
+		Order("depth, id").
+		Find(&pages).Error; err != nil {
+		return nil, err
+	}
+	out.Pages = pageTree(pages)
+
+	if err := database.DB.
+		Where("run_id = ? AND link_id IS NULL", runID).
+		Order("hop").
+		Find(&out.Redirects).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// pageTree nests the flat page list under their parents and returns the
+// roots; siblings keep the order of flat.
+func pageTree(flat []models.Page) []models.Page {
+	children := map[uint64][]int{} // parent ID → indexes into flat
+	var roots []int
+	for i, p := range flat {
+		if p.ParentID == nil {
+			roots = append(roots, i)
+		} else {
+			children[*p.ParentID] = append(children[*p.ParentID], i)
+		}
+	}
+
+	var build func(i int) models.Page
+	build = func(i int) models.Page {
+		p := flat[i]
+		for _, c := range children[p.ID] {
+			p.Children = append(p.Children, build(c))
+		}
+		return p
+	}
+
+	tree := make([]models.Page, 0, len(roots))
+	for _, i := range roots {
+		tree = append(tree, build(i))
+	}
+	return tree
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// change reports a value in two runs.
+type change[T comparable] struct {
+	From    T    `json:"from"`
+	To      T    `json:"to"`
+	Changed bool `json:"changed"`
+}
+
+func newChange[T comparable](from, to T) change[T] {
+	return change[T]{From: from, To: to, Changed: from != to}
+}
+
+// countChange reports a counter in two runs.
+type countChange struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Delta int `json:"delta"`
+}
+
+func newCount(from, to int) countChange {
+	return countChange{From: from, To: to, Delta: to - from}
+}
+
+// statusChange is a link present in both runs whose HTTP status differs.
+type statusChange struct {
+	Href string `json:"href"`
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Kind string `json:"kind"` // broken | fixed | changed
+}
+
+type runDiff struct {
+	From          uint64                 `json:"from"`
+	To            uint64                 `json:"to"`
+	Title         change[string]         `json:"title"`
+	HTMLVersion   change[string]         `json:"html_version"`
+	LoginForm     change[bool]           `json:"login_form"`
+	Headings      map[string]countChange `json:"headings"`
+	Links         map[string]countChange `json:"links"`
+	LinksAdded    []string               `json:"links_added"`
+	LinksRemoved  []string               `json:"links_removed"`
+	StatusChanges []statusChange         `json:"status_changes"`
+}
+
+// finishedRuns are the statuses of runs that ran to an end; the diff
+// defaults skip runs still running or cut short by a shutdown.
+var finishedRuns = []string{"done", "error", "cancelled"}
+
+// DiffRuns compares two stored runs of a URL. ?from= and ?to= are run IDs;
+// to defaults to the latest finished run and from to the finished run
+// before to.
+func DiffRuns(c *gin.Context) {
+	urlRec, ok := ownedURL(c)
+	if !ok {
+		return
+	}
+	id := urlRec.ID
+
+	var to models.CrawlRun
+	toQ := database.DB.Where("url_id = ?", id)
+	if s := c.Query("to"); s != "" {
+		runID, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+		toQ = toQ.Where("id = ?", runID)
+	} else {
+		toQ = toQ.Where("status IN ?", finishedRuns).Order("id DESC")
+	}
+	if err := toQ.First(&to).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+		return
+	}
+
+	var from models.CrawlRun
+	fromQ := database.DB.Where("url_id = ?", id)
+	if s := c.Query("from"); s != "" {
+		runID, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+		fromQ = fromQ.Where("id = ?", runID)
+	} else {
+		fromQ = fromQ.Where("id < ? AND status IN ?", to.ID, finishedRuns).Order("id DESC")
+	}
+	if err := fromQ.First(&from).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+		return
+	}
+
+	var fromLinks, toLinks []models.Link
+	if err := database.DB.Select("href", "http_status").
+		Where("run_id = ?", from.ID).Find(&fromLinks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	if err := database.DB.Select("href", "http_status").
+		Where("run_id = ?", to.ID).Find(&toLinks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	c.JSON(http.StatusOK, diffRuns(&from, &to, fromLinks, toLinks))
+}
+
+// diffRuns builds the structured diff; link lists are sorted by href so
+// the output is stable.
+func diffRuns(from, to *models.CrawlRun, fromLinks, toLinks []models.Link) runDiff {
+	d := runDiff{
+		From:        from.ID,
+		To:          to.ID,
+		Title:       newChange(deref(from.Title), deref(to.Title)),
+		HTMLVersion: newChange(deref(from.HTMLVersion), deref(to.HTMLVersion)),
+		LoginForm:   newChange(from.HasLogin, to.HasLogin),
+		Headings: map[string]countChange{
+			"h1": newCount(from.H1, to.H1),
+			"h2": newCount(from.H2, to.H2),
+			"h3": newCount(from.H3, to.H3),
+		},
+		Links: map[string]countChange{
+			"internal": newCount(from.InternalLinks, to.InternalLinks),
+			"external": newCount(from.ExternalLinks, to.ExternalLinks),
+			"broken":   newCount(from.BrokenLinks, to.BrokenLinks),
+		},
+		LinksAdded:    []string{},
+		LinksRemoved:  []string{},
+		StatusChanges: []statusChange{},
+	}
+
+	before, after := statusByHref(fromLinks), statusByHref(toLinks)
+	for href, st := range after {
+		old, ok := before[href]
+		switch {
+		case !ok:
+			d.LinksAdded = append(d.LinksAdded, href)
+		case old != nil && st != nil && *old != *st:
+			d.StatusChanges = append(d.StatusChanges, statusChange{
+				Href: href, From: *old, To: *st, Kind: statusKind(*old, *st),
+			})
+		}
+	}
+	for href := range before {
+		if _, ok := after[href]; !ok {
+			d.LinksRemoved = append(d.LinksRemoved, href)
+		}
+	}
+
+	sort.Strings(d.LinksAdded)
+	sort.Strings(d.LinksRemoved)
+	sort.Slice(d.StatusChanges, func(i, j int) bool {
+		return d.StatusChanges[i].Href < d.StatusChanges[j].Href
+	})
+	return d
+}
+
+// statusByHref collapses links found on several pages to one entry;
+// within a run every href has a single checked status.
+func statusByHref(links []models.Link) map[string]*int {
+	m := make(map[string]*int, len(links))
+	for _, l := range links {
+		if cur, ok := m[l.Href]; !ok || cur == nil {
+			m[l.Href] = l.HTTPStatus
+		}
+	}
+	return m
+}
+
+// statusKind classifies a status change; 0 means the link could not be
+// reached at all and counts as broken.
+func statusKind(from, to int) string {
+	broken := func(s int) bool { return s == 0 || s >= 400 }
+	switch {
+	case !broken(from) && broken(to):
+		return "broken"
+	case broken(from) && !broken(to):
+		return "fixed"
+	default:
+		return "changed"
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,105 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api/handlers"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestDiffRuns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+
+	u := models.URL{OriginalURL: "https://example.com", UserID: 7}
+	database.DB.Create(&u)
+
+	title1, title2 := "Old", "New"
+	r1 := models.CrawlRun{URLID: u.ID, Status: "done", Title: &title1, H1: 1}
+	r2 := models.CrawlRun{URLID: u.ID, Status: "done", Title: &title2, H1: 3, HasLogin: true}
+	database.DB.Create(&r1)
+	database.DB.Create(&r2)
+	// neither is picked by the defaults
+	database.DB.Create(&models.CrawlRun{URLID: u.ID, Status: "interrupted"})
+	database.DB.Create(&models.CrawlRun{URLID: u.ID, Status: "running"})
+
+	ok, missing, unreachable := 200, 404, 0
+	database.DB.Create(&[]models.Link{
+		{URLID: u.ID, RunID: &r1.ID, Href: "https://example.com/a", HTTPStatus: &ok},
+		{URLID: u.ID, RunID: &r1.ID, Href: "https://example.com/gone", HTTPStatus: &ok},
+		{URLID: u.ID, RunID: &r1.ID, Href: "https://example.com/down", HTTPStatus: &ok},
+		{URLID: u.ID, RunID: &r2.ID, Href: "https://example.com/down", HTTPStatus: &unreachable},
+		{URLID: u.ID, RunID: &r2.ID, Href: "https://example.com/a", HTTPStatus: &missing},
+		{URLID: u.ID, RunID: &r2.ID, Href: "https://example.com/new", HTTPStatus: &ok},
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/urls/1/diff", nil) // defaults: previous → latest
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(u.ID, 10)}}
+	c.Set("uid", uint64(7))
+
+	handlers.DiffRuns(c)
+
+	if w.Code != 200 {
+		t.Fatalf("got %d: %s", w.Code, w.Body)
+	}
+	var d struct {
+		From, To     uint64
+		Title        struct{ Changed bool }
+		LoginForm    struct{ From, To bool } `json:"login_form"`
+		Headings     map[string]struct{ Delta int }
+		LinksAdded   []string `json:"links_added"`
+		LinksRemoved []string `json:"links_removed"`
+		Status       []struct {
+			Href, Kind string
+			From, To   int
+		} `json:"status_changes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.From != r1.ID || d.To != r2.ID || !d.Title.Changed || d.LoginForm.From || !d.LoginForm.To {
+		t.Fatalf("unexpected header: %s", w.Body)
+	}
+	if d.Headings["h1"].Delta != 2 {
+		t.Fatalf("h1 delta = %d; want 2", d.Headings["h1"].Delta)
+	}
+	if len(d.LinksAdded) != 1 || d.LinksAdded[0] != "https://example.com/new" ||
+		len(d.LinksRemoved) != 1 || d.LinksRemoved[0] != "https://example.com/gone" {
+		t.Fatalf("added %v removed %v", d.LinksAdded, d.LinksRemoved)
+	}
+	if len(d.Status) != 2 || d.Status[0].From != 200 || d.Status[0].To != 404 || d.Status[0].Kind != "broken" ||
+		d.Status[1].To != 0 || d.Status[1].Kind != "broken" {
+		t.Fatalf("status changes: %+v", d.Status)
+	}
+
+	// run ids must be numbers
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/urls/1/diff?to=abc", nil)
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(u.ID, 10)}}
+	c.Set("uid", uint64(7))
+	handlers.DiffRuns(c)
+	if w.Code != 400 {
+		t.Fatalf("?to=abc: got %d want 400", w.Code)
+	}
+
+	// someone else's URL is not found
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/urls/1/diff", nil)
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(u.ID, 10)}}
+	c.Set("uid", uint64(8))
+	handlers.DiffRuns(c)
+	if w.Code != 404 {
+		t.Fatalf("foreign URL: got %d want 404", w.Code)
+	}
+}
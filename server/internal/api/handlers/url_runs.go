@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// ListRuns returns the crawl history of a URL, newest first, without the
+// per-run links.
+func ListRuns(c *gin.Context) {
+	u, ok := ownedURL(c)
+	if !ok {
+		return
+	}
+	id := u.ID
+
+	// pagination params
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 || size > 100 {
+		size = 20
+	}
+
+	var runs []models.CrawlRun
+	if err := database.DB.
+		Where("url_id = ?", id).
+		Order("id DESC").
+		Limit(size).
+		Offset((page - 1) * size).
+		Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	var total int64
+	database.DB.Model(&models.CrawlRun{}).Where("url_id = ?", id).Count(&total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  runs,
+		"total": total,
+	})
+}
+
+// GetRun returns one stored crawl run with its links, page tree and
+// redirect chain. Supports the same ?error_kind= filter as GetURLDetail.
+func GetRun(c *gin.Context) {
+	runID, err := strconv.ParseUint(c.Param("runId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run id"})
+		return
+	}
+
+	u, ok := ownedURL(c)
+	if !ok {
+		return
+	}
+	id := u.ID
+
+	var run models.CrawlRun
+	if err := database.DB.
+		Where("id = ? AND url_id = ?", runID, id).
+		First(&run).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	contents, err := loadRunContents(run.ID, c.Query("error_kind"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	run.Links = contents.Links
+	run.Pages = contents.Pages
+	run.Redirects = contents.Redirects
+	if run.Links == nil {
+		run.Links = []models.Link{}
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// minScheduleInterval is the shortest gap allowed between two scheduled
+// crawls of a URL.
+const minScheduleInterval = 5 * time.Minute
+
+type schedulePayload struct {
+	Schedule string `json:"schedule" binding:"required"` // "0 7 * * *", "@daily", "@every 6h"
+}
+
+// SetSchedule sets (or replaces) the recurring crawl schedule of a URL
+// and un-pauses it.
+func SetSchedule(c *gin.Context) {
+	u, ok := ownedURL(c)
+	if !ok {
+		return
+	}
+
+	var body schedulePayload
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "schedule required"})
+		return
+	}
+	spec := strings.TrimSpace(body.Schedule)
+	next, err := crawler.NextRun(spec, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule: " + err.Error()})
+		return
+	}
+	if tooFrequent(spec, next) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "schedule fires more often than every " + minScheduleInterval.String()})
+		return
+	}
+
+	updateSchedule(c, u, map[string]any{
+		"schedule":        spec,
+		"schedule_paused": false,
+		"next_run_at":     next,
+	})
+}
+
+// PauseSchedule keeps the schedule but stops it from firing.
+func PauseSchedule(c *gin.Context) {
+	u, ok := scheduledURL(c)
+	if !ok {
+		return
+	}
+	updateSchedule(c, u, map[string]any{"schedule_paused": true})
+}
+
+// ResumeSchedule re-enables a paused schedule from now on.
+func ResumeSchedule(c *gin.Context) {
+	u, ok := scheduledURL(c)
+	if !ok {
+		return
+	}
+	next, err := crawler.NextRun(*u.Schedule, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid schedule: " + err.Error()})
+		return
+	}
+	updateSchedule(c, u, map[string]any{
+		"schedule_paused": false,
+		"next_run_at":     next,
+	})
+}
+
+// ClearSchedule removes the schedule; the URL is then crawled on demand only.
+func ClearSchedule(c *gin.Context) {
+	u, ok := ownedURL(c)
+	if !ok {
+		return
+	}
+	updateSchedule(c, u, map[string]any{
+		"schedule":        nil,
+		"schedule_paused": false,
+		"next_run_at":     nil,
+	})
+}
+
+// tooFrequent reports whether two of spec's next runs after next are
+// less than minScheduleInterval apart. A cron spec's gaps vary, so a
+// handful of them are checked, not just the first.
+func tooFrequent(spec string, next time.Time) bool {
+	for range 16 {
+		after, err := crawler.NextRun(spec, next)
+		if err != nil || after.Sub(next) < minScheduleInterval {
+			return true
+		}
+		next = after
+	}
+	return false
+}
+
+// scheduledURL loads the caller's URL named by :id; it writes the error
+// response and returns false if there is none or it has no schedule.
+func scheduledURL(c *gin.Context) (*models.URL, bool) {
+	u, ok := ownedURL(c, "schedule")
+	if !ok {
+		return nil, false
+	}
+	if u.Schedule == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "no schedule"})
+		return nil, false
+	}
+	return u, true
+}
+
+// updateSchedule applies fields to u, loaded by the handler through
+// ownedURL, and returns the resulting schedule state.
+func updateSchedule(c *gin.Context, u *models.URL, fields map[string]any) {
+	if err := database.DB.Model(u).Updates(fields).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	if err := database.DB.
+		Select("id", "schedule", "schedule_paused", "next_run_at", "last_run_at").
+		First(u, u.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":              u.ID,
+		"schedule":        u.Schedule,
+		"schedule_paused": u.SchedulePaused,
+		"next_run_at":     u.NextRunAt,
+		"last_run_at":     u.LastRunAt,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api"
+	"github.com/zeewaqar/web-crawler/server/internal/auth"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestScheduleRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	auth.Init("test-secret")
+
+	r := gin.New()
+	api.Register(r)
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		token, _ := auth.NewToken(1)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	u := models.URL{OriginalURL: "https://a.example", UserID: 1}
+	database.DB.Create(&u)
+	path := "/api/v1/urls/1/schedule"
+
+	// someone else's URL is not found, whatever the body
+	token, _ := auth.NewToken(2)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", path, strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(w, req)
+	if w.Code != 404 {
+		t.Errorf("PUT on a foreign URL: got %d want 404", w.Code)
+	}
+
+	// nothing to pause or resume yet
+	for _, action := range []string{"/pause", "/resume"} {
+		if w := call("POST", path+action, ""); w.Code != 409 {
+			t.Errorf("POST %s without schedule: got %d want 409", action, w.Code)
+		}
+	}
+
+	// too frequent, including a cron spec that is only dense within one hour
+	for _, spec := range []string{"@every 1m", "* * * * *", "*/2 9 * * *", "nope"} {
+		if w := call("PUT", path, `{"schedule":"`+spec+`"}`); w.Code != 400 {
+			t.Errorf("PUT %q: got %d want 400", spec, w.Code)
+		}
+	}
+
+	for _, spec := range []string{"@every 5m", "*/10 * * * *", "0 7 * * *"} {
+		if w := call("PUT", path, `{"schedule":"`+spec+`"}`); w.Code != 200 {
+			t.Errorf("PUT %q: got %d %s", spec, w.Code, w.Body)
+		}
+	}
+	if w := call("POST", path+"/pause", ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"schedule_paused":true`) {
+		t.Fatalf("pause: %d %s", w.Code, w.Body)
+	}
+}
@@ -2,15 +2,32 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zeewaqar/web-crawler/server/internal/crawler"
 )
 
+// StopURL cancels a queued crawl or asks a running one to stop. The
+// response says which happened: 200 cancelled, 202 stopping, 409 when
+// there was nothing to stop.
 func StopURL(c *gin.Context) {
-	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	u, ok := ownedURL(c)
+	if !ok {
+		return
+	}
 
-	crawler.Cancel(id)
-	c.Status(http.StatusAccepted)
+	res, err := crawler.Cancel(u.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	switch res {
+	case crawler.StopCancelled:
+		c.JSON(http.StatusOK, gin.H{"stopped": true, "result": res})
+	case crawler.StopRequested:
+		c.JSON(http.StatusAccepted, gin.H{"stopped": true, "result": res})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"stopped": false, "result": res, "error": "not queued or running"})
+	}
 }
@@ -1,54 +1,152 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/zeewaqar/web-crawler/server/internal/crawler"
-	"github.com/zeewaqar/web-crawler/server/internal/database"
-	"github.com/zeewaqar/web-crawler/server/internal/models"
 )
 
+// heartbeat is how often an idle stream sends a comment line so proxies
+// keep the connection open.
+var heartbeat = 15 * time.Second
+
+// StreamProgress relays the events of a URL's crawl as Server-Sent
+// Events: `id:` is "<run>-<seq>" (the crawl run and the event's sequence
+// number within it), `event:` its type and `data:` the crawler.Event as
+// JSON. A client reconnecting with Last-Event-ID gets the events it
+// missed replayed from the crawl's event log. The stream ends after the
+// done event.
 func StreamProgress(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid id"})
+	// 1️⃣  DB check (caller's URLs only): if already finished, emit done and return
+	rec, ok := ownedURL(c)
+	if !ok {
 		return
 	}
+	id := rec.ID
+	last, resumed := parseEventID(c.GetHeader("Last-Event-ID"))
+	finished := rec.CrawlStatus == "done" || rec.CrawlStatus == "error" || rec.CrawlStatus == "cancelled"
 
-	// 1️⃣  DB check: if already done, emit 100 and return
-	var rec models.URL
-	if err := database.DB.Select("crawl_status").First(&rec, id).Error; err != nil {
-		c.JSON(404, gin.H{"error": "not found"})
-		return
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	flusher, _ := c.Writer.(http.Flusher)
+
+	sendDone := func() {
+		sum := crawler.URLSummary(rec)
+		writeEvent(c.Writer, crawler.Event{URLID: id, Type: crawler.EventDone, Pct: 100, Summary: &sum})
 	}
-	if rec.CrawlStatus == "done" || rec.CrawlStatus == "error" {
-		c.Header("Content-Type", "text/event-stream")
-		fmt.Fprintf(c.Writer, "event: progress\ndata: 100\n\n")
+	if finished && !resumed {
+		sendDone()
 		return
 	}
 
-	// 2️⃣  Subscribe for live updates
+	// 2️⃣  Subscribe for live updates, then catch up from the log; live
+	// events already covered by the replay are skipped below
 	ch, cancel := crawler.Subscribe(id)
 	defer cancel()
 
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	flusher, _ := c.Writer.(http.Flusher)
+	emit := func(e crawler.Event) (done bool) {
+		writeEvent(c.Writer, e)
+		last = eventID{e.RunID, e.ID}
+		return e.Type == crawler.EventDone
+	}
+	// catchUp replays what the client has not seen of run, up to but
+	// excluding event before (0: everything logged)
+	catchUp := func(run, before uint64) (done bool) {
+		after := uint64(0)
+		if run == last.run {
+			after = last.seq
+		}
+		if before > 0 && before <= after+1 { // no gap
+			return false
+		}
+		for _, e := range crawler.Replay(id, run, after) {
+			if before > 0 && e.ID >= before {
+				break
+			}
+			if emit(e) {
+				return true
+			}
+		}
+		return false
+	}
 
-	// initial 0 (still running)
-	fmt.Fprintf(c.Writer, "event: progress\ndata: 0\n\n")
+	seen := last
+	if rec.CrawlStatus != "queued" && rec.LatestRunID != nil && catchUp(*rec.LatestRunID, 0) {
+		flusher.Flush()
+		return
+	}
+	if finished { // its log is gone; the stored result still is not
+		sendDone()
+		return
+	}
+	if last == seen {
+		// nothing replayed: initial position of a crawl already under way (0 while queued)
+		cur, _ := crawler.Current(id)
+		writeEvent(c.Writer, crawler.Event{URLID: id, Type: crawler.EventProgress, Pct: cur.Pct})
+	}
 	flusher.Flush()
 
-	for pct := range ch {
-		fmt.Fprintf(c.Writer, "event: progress\ndata: %d\n\n", pct)
-		flusher.Flush()
-		if pct >= 100 {
-			break
+	// 3️⃣  Live events until done or the client leaves. A slow client can
+	// miss events (Publish never blocks); gaps are filled from the log,
+	// which is also checked on every heartbeat in case done was missed.
+	tick := time.NewTicker(heartbeat)
+	defer tick.Stop()
+	for {
+		select {
+		case e, open := <-ch:
+			if !open {
+				return
+			}
+			if e.RunID == last.run && e.ID <= last.seq {
+				continue // already replayed
+			}
+			done := catchUp(e.RunID, e.ID) || emit(e)
+			flusher.Flush()
+			if done {
+				return
+			}
+		case <-tick.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			done := last.run > 0 && catchUp(last.run, 0)
+			flusher.Flush()
+			if done {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
 		}
 	}
 }
+
+// eventID is the position of an event: its crawl run and sequence number.
+type eventID struct{ run, seq uint64 }
+
+// parseEventID reads an SSE id written by writeEvent.
+func parseEventID(s string) (eventID, bool) {
+	var id eventID
+	if _, err := fmt.Sscanf(s, "%d-%d", &id.run, &id.seq); err != nil {
+		return eventID{}, false
+	}
+	return id, true
+}
+
+// writeEvent writes e as one SSE message. Synthesised events (ID 0) have
+// no place in the crawl's sequence and are sent without an id.
+func writeEvent(w io.Writer, e crawler.Event) {
+	if e.ID > 0 {
+		fmt.Fprintf(w, "id: %d-%d\n", e.RunID, e.ID)
+	}
+	writeData(w, e)
+}
+
+// writeData writes e as one SSE message without an id.
+func writeData(w io.Writer, e crawler.Event) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api/repo"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// StreamURLs multiplexes the crawls of all the caller's URLs, or of those
+// listed as ?ids=1,2,3, into one SSE stream. It opens with a status event
+// per URL, then relays status changes and crawl events except per-link
+// results, which only GET /urls/:id/stream carries. The messages have no
+// SSE id; a reconnecting client gets a fresh snapshot instead of a replay.
+func StreamURLs(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	// 1️⃣  optional id filter (caller's URLs only)
+	var ids []uint64
+	if raw := c.Query("ids"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ids"})
+				return
+			}
+			ids = append(ids, id)
+		}
+		var ok bool
+		if ids, ok = ownedIDs(c, ids); !ok {
+			return
+		}
+	}
+	wanted := map[uint64]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	// 2️⃣  subscribe before the snapshot so no change falls in between
+	ch, cancel := crawler.SubscribeUser(uid)
+	defer cancel()
+
+	urls, err := repo.Statuses(uid, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	flusher, _ := c.Writer.(http.Flusher)
+
+	for _, u := range urls {
+		writeData(c.Writer, statusEvent(&u))
+	}
+	flusher.Flush()
+
+	// 3️⃣  live events until the client leaves
+	tick := time.NewTicker(heartbeat)
+	defer tick.Stop()
+	for {
+		select {
+		case e, open := <-ch:
+			if !open {
+				return
+			}
+			if e.Type == crawler.EventLink || (ids != nil && !wanted[e.URLID]) {
+				continue
+			}
+			writeData(c.Writer, e)
+			flusher.Flush()
+		case <-tick.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// statusEvent describes where u's crawl stands, for snapshots.
+func statusEvent(u *models.URL) crawler.Event {
+	e := crawler.Event{URLID: u.ID, Type: crawler.EventStatus, Status: u.CrawlStatus}
+	switch u.CrawlStatus {
+	case "running":
+		cur, _ := crawler.Current(u.ID)
+		e.Pct = cur.Pct
+	case "done":
+		e.Pct = 100
+	}
+	return e
+}
@@ -0,0 +1,134 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api"
+	"github.com/zeewaqar/web-crawler/server/internal/api/handlers"
+	"github.com/zeewaqar/web-crawler/server/internal/auth"
+	"github.com/zeewaqar/web-crawler/server/internal/crawler"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func stream(u *models.URL, lastEventID string) string {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/urls/1/stream", nil)
+	if lastEventID != "" {
+		c.Request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(u.ID, 10)}}
+	c.Set("uid", u.UserID)
+	handlers.StreamProgress(c)
+	return w.Body.String()
+}
+
+func TestStreamReplaysMissedEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+
+	run := uint64(9001) // unique to this test: the broker log is process-wide
+	u := models.URL{OriginalURL: "https://example.com", UserID: 7, CrawlStatus: "running", LatestRunID: &run}
+	database.DB.Create(&u)
+
+	for seq, typ := range []crawler.EventType{crawler.EventProgress, crawler.EventStage, crawler.EventCounts} {
+		crawler.Publish(crawler.Event{ID: uint64(seq + 1), URLID: u.ID, RunID: run, Type: typ})
+	}
+	crawler.Publish(crawler.Event{ID: 4, URLID: u.ID, RunID: run, Type: crawler.EventDone, Pct: 100,
+		Summary: &crawler.Summary{Status: "done"}})
+
+	body := stream(&u, "9001-1")
+	for _, want := range []string{"id: 9001-2\nevent: stage\n", "id: 9001-3\nevent: counts\n", "id: 9001-4\nevent: done\n"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "id: 9001-1\n") {
+		t.Fatalf("replayed an event the client had seen:\n%s", body)
+	}
+
+	// a fresh connection to a running crawl gets the whole log
+	if body := stream(&u, ""); !strings.Contains(body, "id: 9001-1\n") {
+		t.Fatalf("fresh stream did not start at the first event:\n%s", body)
+	}
+}
+
+func TestStreamOfFinishedCrawl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+
+	run := uint64(9002) // nothing logged for it
+	title := "t"
+	u := models.URL{OriginalURL: "https://example.com", UserID: 7, CrawlStatus: "done",
+		LatestRunID: &run, Title: &title, BrokenLinks: 2}
+	database.DB.Create(&u)
+
+	for _, last := range []string{"", "9002-3"} {
+		body := stream(&u, last)
+		if strings.Count(body, "event: ") != 1 || strings.Contains(body, "id: ") ||
+			!strings.Contains(body, `"type":"done"`) || !strings.Contains(body, `"broken_links":2`) {
+			t.Fatalf("Last-Event-ID %q: want one stored done event, got:\n%s", last, body)
+		}
+	}
+}
+
+func TestStreamURLsMultiplexesOwnCrawls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	auth.Init("test-secret")
+
+	mine := models.URL{OriginalURL: "https://a.example", UserID: 1, CrawlStatus: "done"}
+	theirs := models.URL{OriginalURL: "https://b.example", UserID: 2, CrawlStatus: "done"}
+	database.DB.Create(&mine)
+	database.DB.Create(&theirs)
+
+	r := gin.New()
+	api.Register(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/urls/stream", nil)
+	token, _ := auth.NewToken(1)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string { // the data line of the next event
+		for lines.Scan() {
+			if data, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+				return data
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return ""
+	}
+
+	snapshot := `{"id":0,"url_id":` + strconv.FormatUint(mine.ID, 10) + `,"type":"status","pct":100,"status":"done"}`
+	if got := next(); got != snapshot {
+		t.Fatalf("snapshot = %s; want %s", got, snapshot)
+	}
+
+	// a change to someone else's URL is not relayed; one to mine is
+	crawler.Enqueue(crawler.PriorityInteractive, theirs.ID)
+	crawler.Enqueue(crawler.PriorityInteractive, mine.ID)
+	if got := next(); !strings.Contains(got, `"url_id":`+strconv.FormatUint(mine.ID, 10)) ||
+		!strings.Contains(got, `"status":"queued"`) {
+		t.Fatalf("live event = %s; want my URL queued", got)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api/repo"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+	"github.com/zeewaqar/web-crawler/server/internal/webhook"
+	"gorm.io/gorm"
+)
+
+type createWebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"` // done | error | broken_links_increased
+	Secret string   `json:"secret"`                    // generated when empty
+}
+
+// CreateWebhook registers an endpoint for the caller's crawl events. The
+// signing secret is only ever returned here.
+func CreateWebhook(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	// 1️⃣  Validate
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url and events required"})
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must be http(s)"})
+		return
+	}
+	if err := netguard.CheckHost(c.Request.Context(), u.Hostname()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "private or internal address not allowed"})
+		return
+	}
+	var events []string
+	for _, e := range req.Events {
+		if !slices.Contains(webhook.Events, e) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown event " + e})
+			return
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	if len(req.Secret) > 64 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "secret too long"})
+		return
+	}
+	if req.Secret == "" {
+		req.Secret = webhook.NewSecret()
+	}
+
+	// 2️⃣  Store
+	h := models.Webhook{UserID: uid, URL: req.URL, Secret: req.Secret, Events: strings.Join(events, ",")}
+	if err := database.DB.Create(&h).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+
+	// 3️⃣  Respond, once with the secret
+	body := webhookView(&h)
+	body["secret"] = h.Secret
+	c.JSON(http.StatusCreated, body)
+}
+
+// ListWebhooks returns the caller's webhooks, without their secrets.
+func ListWebhooks(c *gin.Context) {
+	uidAny, _ := c.Get("uid")
+	uid := uidAny.(uint64)
+
+	var hooks []models.Webhook
+	if err := database.DB.Where("user_id = ?", uid).Order("id").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	data := make([]gin.H, 0, len(hooks))
+	for i := range hooks {
+		data = append(data, webhookView(&hooks[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// DeleteWebhook removes a webhook along with its deliveries.
+func DeleteWebhook(c *gin.Context) {
+	h, ok := ownedRow(c, repo.Webhook)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		sub := tx.Model(&models.WebhookDelivery{}).Select("id").Where("webhook_id = ?", h.ID)
+		if err := tx.Where("delivery_id IN (?)", sub).Delete(&models.WebhookAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", h.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(h).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": h.ID})
+}
+
+// ListDeliveries returns a webhook's latest 50 deliveries, newest first,
+// each with its attempts.
+func ListDeliveries(c *gin.Context) {
+	h, ok := ownedRow(c, repo.Webhook)
+	if !ok {
+		return
+	}
+	var deliveries []models.WebhookDelivery
+	if err := database.DB.
+		Preload("AttemptLog", func(db *gorm.DB) *gorm.DB { return db.Order("attempt") }).
+		Where("webhook_id = ?", h.ID).
+		Order("id DESC").
+		Limit(50).
+		Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "db"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+func webhookView(h *models.Webhook) gin.H {
+	return gin.H{
+		"id":         h.ID,
+		"url":        h.URL,
+		"events":     strings.Split(h.Events, ","),
+		"created_at": h.CreatedAt,
+	}
+}
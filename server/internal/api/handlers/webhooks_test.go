@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/api"
+	"github.com/zeewaqar/web-crawler/server/internal/auth"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestWebhookRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	test.InitInMemoryDB()
+	auth.Init("test-secret")
+
+	r := gin.New()
+	api.Register(r)
+	call := func(uid uint64, method, path, body string) *httptest.ResponseRecorder {
+		token, _ := auth.NewToken(uid)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// invalid input
+	for _, body := range []string{
+		`{"url":"ftp://x","events":["done"]}`,
+		`{"url":"https://hooks.example","events":["nope"]}`,
+		`{"url":"https://hooks.example","events":[]}`,
+		`{"url":"http://127.0.0.1:8080/hook","events":["done"]}`,
+		`{"url":"http://169.254.169.254/latest/meta-data/","events":["done"]}`,
+		`{"url":"http://localhost/hook","events":["done"]}`,
+	} {
+		if w := call(1, "POST", "/api/v1/webhooks", body); w.Code != 400 {
+			t.Errorf("POST %s: got %d want 400", body, w.Code)
+		}
+	}
+
+	// create: the secret is generated and shown once
+	w := call(1, "POST", "/api/v1/webhooks", `{"url":"https://hooks.example","events":["done","error","done"]}`)
+	if w.Code != 201 {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	var created struct {
+		ID     uint64   `json:"id"`
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if len(created.Secret) != 64 || strings.Join(created.Events, ",") != "done,error" {
+		t.Fatalf("create body: %s", w.Body)
+	}
+	id := strconv.FormatUint(created.ID, 10)
+
+	if w := call(1, "GET", "/api/v1/webhooks", ""); w.Code != 200 || strings.Contains(w.Body.String(), created.Secret) {
+		t.Fatalf("list: %d %s", w.Code, w.Body)
+	}
+
+	// deliveries with their attempts
+	status := 500
+	d := models.WebhookDelivery{WebhookID: created.ID, Event: "done", Payload: "{}", State: "pending", Attempts: 1}
+	database.DB.Create(&d)
+	database.DB.Create(&models.WebhookAttempt{DeliveryID: d.ID, Attempt: 1, StatusCode: &status, Error: "endpoint answered 500"})
+	w = call(1, "GET", "/api/v1/webhooks/"+id+"/deliveries", "")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), `"status_code":500`) {
+		t.Fatalf("deliveries: %d %s", w.Code, w.Body)
+	}
+
+	// someone else's webhook does not exist
+	for _, tc := range []struct{ method, path string }{
+		{"GET", "/api/v1/webhooks/" + id + "/deliveries"},
+		{"DELETE", "/api/v1/webhooks/" + id},
+	} {
+		if w := call(2, tc.method, tc.path, ""); w.Code != 404 {
+			t.Errorf("%s %s as intruder: got %d want 404", tc.method, tc.path, w.Code)
+		}
+	}
+	if w := call(2, "GET", "/api/v1/webhooks", ""); strings.Contains(w.Body.String(), "hooks.example") {
+		t.Errorf("list leaked another user's webhook: %s", w.Body)
+	}
+
+	// delete takes the deliveries along
+	if w := call(1, "DELETE", "/api/v1/webhooks/"+id, ""); w.Code != 200 {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	var left int64
+	database.DB.Model(&models.WebhookAttempt{}).Count(&left)
+	if left != 0 {
+		t.Fatalf("%d attempts left after delete", left)
+	}
+}
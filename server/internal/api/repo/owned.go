@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// Webhook loads webhook id if uid owns it; ErrNotFound otherwise.
+func Webhook(uid, id uint64) (*models.Webhook, error) { return owned[models.Webhook](uid, id) }
+
+// AlertRule loads alert rule id if uid owns it; ErrNotFound otherwise.
+func AlertRule(uid, id uint64) (*models.AlertRule, error) { return owned[models.AlertRule](uid, id) }
+
+// Alert loads alert id if uid owns it; ErrNotFound otherwise.
+func Alert(uid, id uint64) (*models.Alert, error) { return owned[models.Alert](uid, id) }
+
+// owned loads the row of T with id if its user_id is uid.
+func owned[T any](uid, id uint64) (*T, error) {
+	var row T
+	res := database.DB.Where("id = ? AND user_id = ?", id, uid).Limit(1).Find(&row)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &row, nil
+}
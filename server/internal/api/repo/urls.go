@@ -0,0 +1,58 @@
+// Package repo holds the queries that scope URLs, webhooks and alerts to
+// the user who owns them. Handlers go through it so a foreign ID looks
+// exactly like a missing one.
+package repo
+
+import (
+	"errors"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// ErrNotFound means the row does not exist or belongs to someone else.
+var ErrNotFound = errors.New("not found")
+
+// URL loads URL id if uid owns it. cols limits the loaded columns; id
+// and user_id are always included.
+func URL(uid, id uint64, cols ...string) (*models.URL, error) {
+	q := database.DB.Where("id = ? AND user_id = ?", id, uid)
+	if len(cols) > 0 {
+		q = q.Select(append([]string{"id", "user_id"}, cols...))
+	}
+
+	var u models.URL
+	res := q.Limit(1).Find(&u) // Find: a miss is routine here, not worth a log line
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+// OwnedURLIDs returns the ids among ids that uid owns, ascending.
+func OwnedURLIDs(uid uint64, ids []uint64) ([]uint64, error) {
+	owned := []uint64{}
+	if len(ids) == 0 {
+		return owned, nil
+	}
+	err := database.DB.Model(&models.URL{}).
+		Where("user_id = ? AND id IN ?", uid, ids).
+		Order("id").
+		Pluck("id", &owned).Error
+	return owned, err
+}
+
+// Statuses loads id and crawl_status of uid's URLs, limited to ids
+// unless ids is nil, ascending by id.
+func Statuses(uid uint64, ids []uint64) ([]models.URL, error) {
+	q := database.DB.Select("id", "crawl_status").Where("user_id = ?", uid)
+	if ids != nil {
+		q = q.Where("id IN ?", ids)
+	}
+	var urls []models.URL
+	err := q.Order("id").Find(&urls).Error
+	return urls, err
+}
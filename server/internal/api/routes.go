@@ -24,11 +24,42 @@ func Register(r *gin.Engine) {
 		secured.PUT("/urls/:id/stop", handlers.StopURL)
 		secured.POST("/urls/bulk/stop", handlers.BulkStop)
 		secured.GET("/urls", handlers.ListURLs)
+		secured.GET("/urls/stream", handlers.StreamURLs)
 		secured.GET("/urls/:id", handlers.GetURLDetail)
 		secured.GET("/urls/:id/stream", handlers.StreamProgress)
+		secured.GET("/urls/:id/runs", handlers.ListRuns)
+		secured.GET("/urls/:id/runs/:runId", handlers.GetRun)
+		secured.GET("/urls/:id/diff", handlers.DiffRuns)
+		secured.PUT("/urls/:id/schedule", handlers.SetSchedule)
+		secured.POST("/urls/:id/schedule/pause", handlers.PauseSchedule)
+		secured.POST("/urls/:id/schedule/resume", handlers.ResumeSchedule)
+		secured.DELETE("/urls/:id/schedule", handlers.ClearSchedule)
+		secured.GET("/jobs", handlers.ListJobs)
+		secured.POST("/webhooks", handlers.CreateWebhook)
+		secured.GET("/webhooks", handlers.ListWebhooks)
+		secured.DELETE("/webhooks/:id", handlers.DeleteWebhook)
+		secured.GET("/webhooks/:id/deliveries", handlers.ListDeliveries)
+		secured.POST("/alert-rules", handlers.CreateAlertRule)
+		secured.GET("/alert-rules", handlers.ListAlertRules)
+		secured.DELETE("/alert-rules/:id", handlers.DeleteAlertRule)
+		secured.GET("/alerts", handlers.ListAlerts)
+		secured.POST("/alerts/:id/acknowledge", handlers.AcknowledgeAlert)
+		secured.POST("/alerts/:id/resolve", handlers.ResolveAlert)
+		secured.GET("/ws", handlers.CrawlSocket) // WebSocket: subscribe & control
 		// …any other modifying endpoints
 	}
 
+	admin := v1.Group("/queue")
+	admin.Use(auth.RequireJWT(), auth.RequireAdmin()) // 🔒 admins only
+	{
+		admin.GET("", handlers.GetQueue)
+		admin.POST("/pause", handlers.PauseQueue)
+		admin.POST("/resume", handlers.ResumeQueue)
+		admin.POST("/users/:userId/drain", handlers.DrainUserQueue)
+		admin.GET("/workers", handlers.ListWorkers)
+		admin.PUT("/workers", handlers.ResizeWorkers)
+	}
+
 	// read-only endpoints can stay outside if desired
 
 }
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// RequireAdmin lets through only users flagged is_admin. It must run
+// after RequireJWT, which sets "uid".
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := c.Get("uid")
+
+		var u models.User
+		if err := database.DB.Select("id", "is_admin").First(&u, uid).Error; err != nil || !u.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin only"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,184 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+)
+
+// Broker relays crawl events and cancel requests between the
+// process running a crawl and the processes serving its clients. The
+// default LocalBroker only works within one process; DBBroker lets API
+// and cmd/worker processes share them through the database.
+type Broker interface {
+	// Publish hands e to every subscriber of e.URLID and e.UserID;
+	// status events only reach the latter. It must not block.
+	Publish(e Event)
+	// Subscribe returns a channel of events for urlID and a func that
+	// unsubscribes and closes it.
+	Subscribe(urlID uint64) (EventCh, func())
+	// SubscribeUser is Subscribe for every URL of userID, status events
+	// included.
+	SubscribeUser(userID uint64) (EventCh, func())
+	// Last returns the latest event of a crawl that has not finished.
+	Last(urlID uint64) (Event, bool)
+	// Replay returns the logged events of urlID's crawl run whose ID is
+	// above after, oldest first. Only the newest logSize events of a run
+	// are kept, and only for a while after it ends.
+	Replay(urlID, run, after uint64) []Event
+	// Cancel asks whichever process runs urlID's crawl to stop it. This
+	// is the fast path; workers also poll the job's durable cancel flag.
+	Cancel(urlID uint64)
+	// OnCancel sets the func that stops crawls running in this process.
+	OnCancel(fn func(urlID uint64))
+}
+
+var (
+	logSize      = 256              // events kept per crawl for Replay
+	logRetention = 10 * time.Minute // how long a finished crawl's log is kept
+)
+
+var broker Broker = NewLocalBroker()
+
+func init() { broker.OnCancel(stopRequested) }
+
+// SetBroker replaces the broker; call it before starting workers or
+// serving streams.
+func SetBroker(b Broker) {
+	b.OnCancel(stopRequested)
+	broker = b
+}
+
+/*──────────────── in-process broker ────────────────*/
+
+// LocalBroker fans events out to subscribers in the same process.
+type LocalBroker struct {
+	mu        sync.RWMutex
+	listeners map[uint64][]EventCh // urlID → fan-out list
+	users     map[uint64][]EventCh // userID → fan-out list
+	logs      map[uint64][]Event   // urlID → latest events of its newest crawl
+	onCancel  func(uint64)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{
+		listeners: make(map[uint64][]EventCh),
+		users:     make(map[uint64][]EventCh),
+		logs:      make(map[uint64][]Event),
+	}
+}
+
+func (b *LocalBroker) Subscribe(urlID uint64) (EventCh, func()) {
+	return b.subscribe(b.listeners, urlID, 64)
+}
+
+func (b *LocalBroker) SubscribeUser(userID uint64) (EventCh, func()) {
+	return b.subscribe(b.users, userID, 256) // many crawls share it
+}
+
+// subscribe adds a channel of size buffered events to the fan-out list
+// subs[key].
+func (b *LocalBroker) subscribe(subs map[uint64][]EventCh, key uint64, size int) (EventCh, func()) {
+	ch := make(EventCh, size)
+
+	b.mu.Lock()
+	subs[key] = append(subs[key], ch)
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, c := range subs[key] {
+			if c == ch {
+				subs[key] = append(subs[key][:i], subs[key][i+1:]...)
+				close(c)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+func (b *LocalBroker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e.Type != EventStatus {
+		b.record(e)
+		send(b.listeners[e.URLID], e)
+	}
+	send(b.users[e.UserID], e)
+}
+
+// record appends e to its crawl's log.
+func (b *LocalBroker) record(e Event) {
+	log := b.logs[e.URLID]
+	if len(log) > 0 && log[0].RunID != e.RunID { // a new crawl starts afresh
+		log = nil
+	}
+	if len(log) == logSize {
+		log = log[1:]
+	}
+	b.logs[e.URLID] = append(log, e)
+	if e.Type == EventDone {
+		time.AfterFunc(logRetention, func() { b.expire(e) })
+	}
+}
+
+func send(subs []EventCh, e Event) {
+	for _, ch := range subs {
+		select { // don’t block if client is slow
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// expire drops the log ended by done unless a newer crawl replaced it.
+func (b *LocalBroker) expire(done Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if log := b.logs[done.URLID]; len(log) > 0 && log[len(log)-1] == done {
+		delete(b.logs, done.URLID)
+	}
+}
+
+func (b *LocalBroker) Last(urlID uint64) (Event, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	log := b.logs[urlID]
+	if len(log) == 0 || log[len(log)-1].Type == EventDone {
+		return Event{}, false
+	}
+	return log[len(log)-1], true
+}
+
+func (b *LocalBroker) Replay(urlID, run, after uint64) []Event {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return missed(b.logs[urlID], run, after)
+}
+
+func (b *LocalBroker) Cancel(urlID uint64) {
+	b.mu.RLock()
+	fn := b.onCancel
+	b.mu.RUnlock()
+	if fn != nil {
+		fn(urlID)
+	}
+}
+
+func (b *LocalBroker) OnCancel(fn func(uint64)) {
+	b.mu.Lock()
+	b.onCancel = fn
+	b.mu.Unlock()
+}
+
+// missed picks the events of run after ID after from log, oldest first.
+func missed(log []Event, run, after uint64) []Event {
+	var out []Event
+	for _, e := range log {
+		if e.RunID == run && e.ID > after {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,301 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+/*──────────────── database-polling broker ────────────────
+ * Every signal is a row in crawl_signals. Subscribers and the cancel
+ * listener poll for rows they have not seen yet, so any number of API
+ * and worker processes sharing the database see each other's signals
+ * within one poll interval. The rows double as the event log behind
+ * Replay. Rows older than signalRetention are pruned by the cancel
+ * listener.
+ *
+ * Auto-increment ids are handed out at insert but become visible at
+ * commit, so with concurrent publishers a lower id can show up after a
+ * higher one was read. Polls therefore re-read the rows of the last
+ * signalLag (see cursor) instead of only those above the newest id seen.
+ *
+ * Link events come one per checked link, so they are batched: a row
+ * holds all the link events of a URL published within one interval, and
+ * is written before any other event of that URL to keep the order.
+ *──────────────────────────────────────────────────────────*/
+
+var (
+	signalRetention = logRetention    // as long as LocalBroker keeps a log
+	signalLag       = 5 * time.Second // longer than any single-row insert takes to commit
+)
+
+// DBBroker relays signals through the shared database.
+type DBBroker struct {
+	interval time.Duration
+
+	mu         sync.Mutex
+	stopCancel context.CancelFunc // stops the OnCancel poller
+
+	pub   sync.Mutex         // orders the rows this process writes
+	links map[uint64][]Event // urlID → link events not written yet
+}
+
+// NewDBBroker returns a broker that polls every interval.
+func NewDBBroker(interval time.Duration) *DBBroker {
+	return &DBBroker{interval: interval, links: map[uint64][]Event{}}
+}
+
+func (b *DBBroker) Publish(e Event) {
+	if e.Type == EventLink {
+		b.pub.Lock()
+		if len(b.links[e.URLID]) == 0 {
+			time.AfterFunc(b.interval, func() {
+				b.pub.Lock()
+				defer b.pub.Unlock()
+				b.flushLinks(e.URLID)
+			})
+		}
+		b.links[e.URLID] = append(b.links[e.URLID], e)
+		b.pub.Unlock()
+		return
+	}
+
+	kind := "event"
+	if e.Type == EventStatus {
+		kind = "status"
+	}
+	payload, _ := json.Marshal(e)
+
+	b.pub.Lock()
+	defer b.pub.Unlock()
+	b.flushLinks(e.URLID)
+	b.insert(&models.CrawlSignal{URLID: e.URLID, UserID: e.UserID, Kind: kind, Payload: string(payload)})
+}
+
+// flushLinks writes the pending link events of urlID as one row; b.pub
+// must be held.
+func (b *DBBroker) flushLinks(urlID uint64) {
+	batch := b.links[urlID]
+	if len(batch) == 0 {
+		return
+	}
+	delete(b.links, urlID)
+	payload, _ := json.Marshal(batch)
+	b.insert(&models.CrawlSignal{URLID: urlID, UserID: batch[0].UserID, Kind: "event", Payload: string(payload)})
+}
+
+func (b *DBBroker) insert(r *models.CrawlSignal) {
+	if err := database.DB.Create(r).Error; err != nil {
+		log.Println("broker publish:", err)
+	}
+}
+
+func (b *DBBroker) Subscribe(urlID uint64) (EventCh, func()) {
+	return b.follow(64, "url_id = ? AND kind = ?", urlID, "event")
+}
+
+func (b *DBBroker) SubscribeUser(userID uint64) (EventCh, func()) {
+	return b.follow(256, "user_id = ? AND kind IN ?", userID, []string{"event", "status"})
+}
+
+// follow delivers the event rows matching where that are published from
+// now on, on a channel of size buffered events.
+func (b *DBBroker) follow(size int, where string, args ...any) (EventCh, func()) {
+	ch := make(EventCh, size)
+	ctx, stop := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	cur := newCursor()
+
+	go func() {
+		defer close(exited)
+		b.poll(ctx, func() {
+			var rows []models.CrawlSignal
+			database.DB.Where("id > ?", cur.floor).Where(where, args...).
+				Order("id").Find(&rows)
+			for _, r := range cur.next(rows) {
+				for _, e := range decodeEvents(&r) {
+					select {
+					case ch <- e:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		})
+	}()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			stop()
+			<-exited
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+func (b *DBBroker) Last(urlID uint64) (Event, bool) {
+	var r models.CrawlSignal
+	res := database.DB.
+		Where("url_id = ? AND kind = ?", urlID, "event").
+		Order("id DESC").Limit(1).Find(&r)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return Event{}, false
+	}
+	events := decodeEvents(&r)
+	if len(events) == 0 || events[len(events)-1].Type == EventDone {
+		return Event{}, false
+	}
+	return events[len(events)-1], true
+}
+
+func (b *DBBroker) Replay(urlID, run, after uint64) []Event {
+	var rows []models.CrawlSignal
+	database.DB.
+		Where("url_id = ? AND kind = ?", urlID, "event").
+		Order("id DESC").Limit(logSize).Find(&rows)
+
+	var log []Event
+	for i := len(rows) - 1; i >= 0; i-- {
+		log = append(log, decodeEvents(&rows[i])...)
+	}
+	return missed(log[max(len(log)-logSize, 0):], run, after)
+}
+
+func (b *DBBroker) Cancel(urlID uint64) {
+	if err := database.DB.Create(&models.CrawlSignal{URLID: urlID, Kind: "cancel"}).Error; err != nil {
+		log.Println("broker cancel:", err)
+	}
+}
+
+func (b *DBBroker) OnCancel(fn func(uint64)) {
+	ctx, stop := context.WithCancel(context.Background())
+	b.mu.Lock()
+	if b.stopCancel != nil {
+		b.stopCancel()
+	}
+	b.stopCancel = stop
+	b.mu.Unlock()
+
+	cur := newCursor()
+	go func() {
+		pruned := time.Now()
+		b.poll(ctx, func() {
+			var rows []models.CrawlSignal
+			database.DB.Where("id > ? AND kind = ?", cur.floor, "cancel").Order("id").Find(&rows)
+			for _, r := range cur.next(rows) {
+				fn(r.URLID)
+			}
+
+			if time.Since(pruned) > signalRetention/10 {
+				pruned = time.Now()
+				database.DB.Where("created_at < ?", pruned.Add(-signalRetention)).
+					Delete(&models.CrawlSignal{})
+			}
+		})
+	}()
+}
+
+// Close writes the pending link events and stops the cancel listener;
+// subscriptions end through their own cancel funcs.
+func (b *DBBroker) Close() {
+	b.pub.Lock()
+	for urlID := range b.links {
+		b.flushLinks(urlID)
+	}
+	b.pub.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stopCancel != nil {
+		b.stopCancel()
+		b.stopCancel = nil
+	}
+}
+
+// poll calls fn every interval until ctx is done.
+func (b *DBBroker) poll(ctx context.Context, fn func()) {
+	t := time.NewTicker(b.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			fn()
+		}
+	}
+}
+
+// decodeEvents unpacks the payload of an event row: one event, or a
+// batch of link events.
+func decodeEvents(r *models.CrawlSignal) []Event {
+	var events []Event
+	var err error
+	if strings.HasPrefix(r.Payload, "[") {
+		err = json.Unmarshal([]byte(r.Payload), &events)
+	} else {
+		events = make([]Event, 1)
+		err = json.Unmarshal([]byte(r.Payload), &events[0])
+	}
+	if err != nil {
+		log.Println("broker decode:", err)
+		return nil
+	}
+	for i := range events {
+		events[i].UserID = r.UserID // not part of the JSON
+	}
+	return events
+}
+
+// cursor tracks the signal rows one poller has handled. Rows up to floor
+// are settled; above it, seen holds the ids already handled, so rows
+// committed out of id order are still picked up exactly once.
+type cursor struct {
+	floor uint64
+	seen  map[uint64]bool
+}
+
+// newCursor starts after the newest row: subscribers get what is
+// published from now on.
+func newCursor() *cursor { return &cursor{floor: lastSignalID(), seen: map[uint64]bool{}} }
+
+// next returns the rows (read in id order, all above floor) not handled
+// yet and moves floor past the rows older than signalLag, as any row
+// still to commit below them would have done so by now.
+func (c *cursor) next(rows []models.CrawlSignal) []models.CrawlSignal {
+	settled := time.Now().Add(-signalLag)
+	var fresh []models.CrawlSignal
+	floor := c.floor
+	for _, r := range rows {
+		if !c.seen[r.ID] {
+			c.seen[r.ID] = true
+			fresh = append(fresh, r)
+		}
+		if r.CreatedAt.Before(settled) {
+			floor = r.ID
+		}
+	}
+	if floor != c.floor {
+		c.floor = floor
+		for id := range c.seen {
+			if id <= floor {
+				delete(c.seen, id)
+			}
+		}
+	}
+	return fresh
+}
+
+func lastSignalID() uint64 {
+	var id uint64
+	database.DB.Model(&models.CrawlSignal{}).Select("COALESCE(MAX(id), 0)").Scan(&id)
+	return id
+}
@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestDBBrokerRelaysEventsAndCancel(t *testing.T) {
+	test.InitInMemoryDB()
+	b := NewDBBroker(5 * time.Millisecond)
+	defer b.Close()
+
+	cancelled := make(chan uint64, 1)
+	b.OnCancel(func(id uint64) { cancelled <- id })
+
+	ch, unsubscribe := b.Subscribe(7)
+	defer unsubscribe()
+
+	b.Publish(Event{ID: 1, URLID: 8, Type: EventProgress, Pct: 50}) // other URL: not delivered
+	b.Publish(Event{ID: 1, URLID: 7, Type: EventWait, Pct: 40, WaitMs: 250})
+	b.Publish(Event{ID: 2, URLID: 7, Type: EventDone, Pct: 100, Summary: &Summary{Status: "done"}})
+
+	for _, want := range []Event{
+		{ID: 1, URLID: 7, Type: EventWait, Pct: 40, WaitMs: 250},
+		{ID: 2, URLID: 7, Type: EventDone, Pct: 100, Summary: &Summary{Status: "done"}},
+	} {
+		select {
+		case got := <-ch:
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %+v; want %+v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %+v", want)
+		}
+	}
+
+	if _, ok := b.Last(7); ok {
+		t.Fatal("finished crawl still reported as running")
+	}
+	if p, ok := b.Last(8); !ok || p.Pct != 50 {
+		t.Fatalf("Last(8) = %+v, %v", p, ok)
+	}
+
+	b.Cancel(7)
+	select {
+	case id := <-cancelled:
+		if id != 7 {
+			t.Fatalf("cancelled %d; want 7", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("cancel not relayed")
+	}
+}
+
+func TestDBBrokerSeesSignalsCommittedOutOfOrder(t *testing.T) {
+	test.InitInMemoryDB()
+	b := NewDBBroker(5 * time.Millisecond)
+	defer b.Close()
+
+	cancelled := make(chan uint64, 4)
+	b.OnCancel(func(id uint64) { cancelled <- id })
+	wait := func(want uint64) {
+		t.Helper()
+		select {
+		case id := <-cancelled:
+			if id != want {
+				t.Fatalf("cancelled %d; want %d", id, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("cancel of %d not relayed", want)
+		}
+	}
+
+	// id 10 commits first; id 5, handed out earlier, becomes visible after
+	database.DB.Create(&models.CrawlSignal{ID: 10, URLID: 1, Kind: "cancel"})
+	wait(1)
+	database.DB.Create(&models.CrawlSignal{ID: 5, URLID: 2, Kind: "cancel"})
+	wait(2)
+
+	select {
+	case id := <-cancelled:
+		t.Fatalf("cancel of %d relayed twice", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDBBrokerBatchesLinkEvents(t *testing.T) {
+	test.InitInMemoryDB()
+	b := NewDBBroker(5 * time.Millisecond)
+	defer b.Close()
+
+	ch, unsubscribe := b.Subscribe(7)
+	defer unsubscribe()
+
+	for i := uint64(1); i <= 3; i++ {
+		b.Publish(Event{ID: i, URLID: 7, RunID: 1, Type: EventLink, Link: &LinkEvent{Href: fmt.Sprint(i)}})
+	}
+	b.Publish(Event{ID: 4, URLID: 7, RunID: 1, Type: EventDone, Summary: &Summary{Status: "done"}})
+
+	for want := uint64(1); want <= 4; want++ {
+		select {
+		case e := <-ch:
+			if e.ID != want {
+				t.Fatalf("got event %d; want %d", e.ID, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", want)
+		}
+	}
+
+	var rows int64
+	database.DB.Model(&models.CrawlSignal{}).Count(&rows)
+	if rows != 2 {
+		t.Fatalf("%d signal rows; want 2 (the links, then done)", rows)
+	}
+	if got := b.Replay(7, 1, 0); len(got) != 4 || got[2].Link == nil || got[2].Link.Href != "3" {
+		t.Fatalf("Replay = %+v", got)
+	}
+}
+
+func TestLocalBrokerReplaysLog(t *testing.T) {
+	defer func(n int) { logSize = n }(logSize)
+	logSize = 4
+
+	b := NewLocalBroker()
+	for seq := uint64(1); seq <= 6; seq++ {
+		b.Publish(Event{ID: seq, URLID: 7, RunID: 1, Type: EventProgress, Pct: int(seq)})
+	}
+
+	ids := func(events []Event) (out []uint64) {
+		for _, e := range events {
+			out = append(out, e.ID)
+		}
+		return out
+	}
+	if got := ids(b.Replay(7, 1, 4)); !reflect.DeepEqual(got, []uint64{5, 6}) {
+		t.Fatalf("replay after 4 = %v; want [5 6]", got)
+	}
+	if got := ids(b.Replay(7, 1, 0)); !reflect.DeepEqual(got, []uint64{3, 4, 5, 6}) {
+		t.Fatalf("replay of bounded log = %v; want [3 4 5 6]", got)
+	}
+
+	b.Publish(Event{ID: 7, URLID: 7, RunID: 1, Type: EventDone, Pct: 100})
+	if _, ok := b.Last(7); ok {
+		t.Fatal("finished crawl still reported as running")
+	}
+	if got := ids(b.Replay(7, 1, 6)); !reflect.DeepEqual(got, []uint64{7}) {
+		t.Fatalf("done not replayable: %v", got)
+	}
+
+	b.Publish(Event{ID: 1, URLID: 7, RunID: 2, Type: EventProgress})
+	if got := b.Replay(7, 1, 0); len(got) != 0 {
+		t.Fatalf("old run still logged: %v", ids(got))
+	}
+	if got := ids(b.Replay(7, 2, 0)); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Fatalf("replay of new run = %v; want [1]", got)
+	}
+}
+
+func TestSubscribeUserGetsAllCrawlsAndStatus(t *testing.T) {
+	test.InitInMemoryDB()
+	db := NewDBBroker(5 * time.Millisecond)
+	defer db.Close()
+
+	for _, b := range []Broker{NewLocalBroker(), db} {
+		mine, unsubscribe := b.SubscribeUser(3)
+		perURL, unsubscribeURL := b.Subscribe(7)
+
+		b.Publish(Event{ID: 1, URLID: 9, UserID: 4, Type: EventProgress}) // someone else's
+		b.Publish(Event{URLID: 7, UserID: 3, Type: EventStatus, Status: "running"})
+		b.Publish(Event{ID: 1, URLID: 7, UserID: 3, RunID: 2, Type: EventProgress, Pct: 5})
+		b.Publish(Event{ID: 1, URLID: 8, UserID: 3, RunID: 3, Type: EventProgress, Pct: 9})
+
+		var got []string
+		for len(got) < 3 {
+			select {
+			case e := <-mine:
+				got = append(got, fmt.Sprintf("%d:%s:%d", e.URLID, e.Type, e.UserID))
+			case <-time.After(time.Second):
+				t.Fatalf("%T: timed out with %v", b, got)
+			}
+		}
+		if want := []string{"7:status:3", "7:progress:3", "8:progress:3"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%T: user stream = %v; want %v", b, got, want)
+		}
+		select {
+		case e := <-perURL:
+			if e.Type != EventProgress {
+				t.Fatalf("%T: per-URL stream got %s", b, e.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%T: per-URL stream got nothing", b)
+		}
+
+		unsubscribe()
+		unsubscribeURL()
+	}
+}
@@ -0,0 +1,71 @@
+package crawler
+
+import (
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+/*──────────────── stopping crawls ────────────────
+ * A stop request is stored on the URL's job so it survives restarts and
+ * reaches crawls in any process: a queued job is cancelled on the spot,
+ * a leased one gets cancel_requested_at, which its worker notices through
+ * the broker at once or by polling within cancelPoll.
+ *──────────────────────────────────────────────────*/
+
+// cancelPoll is how often a running job re-reads its cancel flag.
+var cancelPoll = 2 * time.Second
+
+// StopResult says what a stop request did.
+type StopResult string
+
+const (
+	StopCancelled  StopResult = "cancelled"   // was queued; will not run
+	StopRequested  StopResult = "stopping"    // is running; ends within moments
+	StopNotRunning StopResult = "not_running" // nothing queued or running
+)
+
+// Cancel stops the crawl of URL id, whether it is still queued or
+// running in any process.
+func Cancel(id uint64) (StopResult, error) {
+	now := time.Now()
+
+	res := database.DB.Model(&models.Job{}).
+		Where("url_id = ? AND state = ?", id, "queued").
+		Updates(map[string]any{"state": "cancelled", "cancel_requested_at": now})
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected > 0 {
+		err := database.DB.Model(&models.URL{}).Where("id = ?", id).
+			Update("crawl_status", "cancelled").Error
+		announce(id)
+		return StopCancelled, err
+	}
+
+	res = database.DB.Model(&models.Job{}).
+		Where("url_id = ? AND state = ? AND cancel_requested_at IS NULL", id, "leased").
+		Update("cancel_requested_at", now)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected == 0 && !cancelRequested(id) {
+		return StopNotRunning, nil
+	}
+	broker.Cancel(id)
+	return StopRequested, nil
+}
+
+// cancelRequested reports whether the running job of URL id was asked
+// to stop.
+func cancelRequested(id uint64) bool {
+	var n int64
+	database.DB.Model(&models.Job{}).
+		Where("url_id = ? AND state = ? AND cancel_requested_at IS NOT NULL", id, "leased").
+		Count(&n)
+	return n > 0
+}
+
+// stopRequested is the broker's cancel handler.
+func stopRequested(id uint64) { cancelLocal(id, errCancelled) }
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestCancelQueuedJob(t *testing.T) {
+	test.InitInMemoryDB()
+	ids := seedURLs(1)
+	Enqueue(PriorityBulk, ids...)
+
+	if res, err := Cancel(ids[0]); res != StopCancelled || err != nil {
+		t.Fatalf("Cancel = %q, %v; want cancelled", res, err)
+	}
+	if j, _ := claim("w"); j != nil {
+		t.Fatalf("claimed cancelled job %+v", j)
+	}
+	var u models.URL
+	database.DB.First(&u, ids[0])
+	if u.CrawlStatus != "cancelled" {
+		t.Fatalf("status = %s; want cancelled", u.CrawlStatus)
+	}
+
+	if res, _ := Cancel(ids[0]); res != StopNotRunning {
+		t.Fatalf("second Cancel = %q; want not_running", res)
+	}
+}
+
+func TestCancelRunningJobFromAnotherProcess(t *testing.T) {
+	test.InitInMemoryDB()
+	defer func(d time.Duration) { cancelPoll = d }(cancelPoll)
+	cancelPoll = 20 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		<-r.Context().Done() // hang until the crawl is cancelled
+	}))
+	defer srv.Close()
+
+	u := models.URL{OriginalURL: srv.URL + "/", UserID: 1}
+	database.DB.Create(&u)
+	Enqueue(PriorityInteractive, u.ID)
+
+	p := NewPool(1)
+	defer p.Shutdown(t.Context())
+	waitFor(t, "crawl to start", func() bool { return p.States()[0].State == "crawling" })
+
+	// what Cancel in another process leaves behind; no broker signal here
+	database.DB.Model(&models.Job{}).Where("url_id = ?", u.ID).
+		Update("cancel_requested_at", time.Now())
+
+	waitFor(t, "crawl to stop", func() bool {
+		database.DB.First(&u, u.ID)
+		return u.CrawlStatus == "cancelled"
+	})
+	waitFor(t, "job to close", func() bool {
+		var j models.Job
+		database.DB.First(&j)
+		return j.State == "cancelled"
+	})
+	var run models.CrawlRun
+	database.DB.First(&run)
+	if run.Status != "cancelled" {
+		t.Fatalf("run status = %s; want cancelled", run.Status)
+	}
+}
@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+)
+
+// Config holds the crawler settings that main() reads from the environment.
+// Zero values fall back to the defaults below.
+type Config struct {
+	UserAgent       string        // sent on every request and matched against robots.txt
+	HostConcurrency int           // max requests in flight per host, across all workers
+	HostInterval    time.Duration // min gap between request starts per host (0 = none)
+	LinkWorkers     int           // concurrent link checks within one crawl
+	MaxPageBytes    int64         // page bodies are read up to this size
+
+	LongRedirectChain int // chains with more redirects than this are flagged
+	QueueCapacity     int // max jobs waiting for a worker before Enqueue rejects
+	Workers           int // initial size of the worker pool, -1 = none
+
+	AllowHosts []string // hosts, IPs and CIDRs exempt from the SSRF guard, e.g. an intranet site
+}
+
+var defaultConfig = Config{
+	UserAgent:       "WebCrawlerBot/1.0",
+	HostConcurrency: 4,
+	HostInterval:    0,
+	LinkWorkers:     8,
+	MaxPageBytes:    5 << 20,
+
+	LongRedirectChain: 3,
+	QueueCapacity:     1000,
+	Workers:           runtime.NumCPU() * 2,
+}
+
+var cfg = defaultConfig
+
+// Init applies c on top of the defaults; call it before starting workers.
+func Init(c Config) {
+	cfg = defaultConfig
+	if c.UserAgent != "" {
+		cfg.UserAgent = c.UserAgent
+	}
+	if c.HostConcurrency > 0 {
+		cfg.HostConcurrency = c.HostConcurrency
+	}
+	if c.HostInterval > 0 {
+		cfg.HostInterval = c.HostInterval
+	}
+	if c.LinkWorkers > 0 {
+		cfg.LinkWorkers = c.LinkWorkers
+	}
+	if c.MaxPageBytes > 0 {
+		cfg.MaxPageBytes = c.MaxPageBytes
+	}
+	if c.LongRedirectChain > 0 {
+		cfg.LongRedirectChain = c.LongRedirectChain
+	}
+	if c.QueueCapacity > 0 {
+		cfg.QueueCapacity = c.QueueCapacity
+	}
+	if c.Workers > 0 {
+		cfg.Workers = min(c.Workers, MaxWorkers)
+	} else if c.Workers < 0 { // crawling happens in cmd/worker processes only
+		cfg.Workers = 0
+	}
+	if len(c.AllowHosts) > 0 {
+		cfg.AllowHosts = c.AllowHosts
+	}
+	netguard.Allow(cfg.AllowHosts)
+}
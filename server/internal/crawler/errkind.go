@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+)
+
+// Error kinds stored on models.Link when a check fails without a status.
+const (
+	ErrKindDNS              = "dns"
+	ErrKindTLS              = "tls"
+	ErrKindTimeout          = "timeout"
+	ErrKindRefused          = "refused"
+	ErrKindReset            = "reset"
+	ErrKindTooManyRedirects = "too_many_redirects"
+	ErrKindInvalidURL       = "invalid_url"
+	ErrKindBlocked          = "blocked" // the SSRF guard refused the address
+	ErrKindOther            = "other"
+)
+
+var errTooManyRedirects = errors.New("stopped after 10 redirects")
+
+// maxErrorMessage bounds the raw error text persisted per link.
+const maxErrorMessage = 1024
+
+// classify maps a request error to one of the ErrKind constants and the
+// raw message to store with it; nil yields empty strings.
+func classify(err error) (kind, msg string) {
+	if err == nil {
+		return "", ""
+	}
+	msg = err.Error()
+	if len(msg) > maxErrorMessage {
+		msg = msg[:maxErrorMessage]
+	}
+
+	var (
+		dnsErr   *net.DNSError
+		urlErr   *url.Error
+		netErr   net.Error
+		certErr  *tls.CertificateVerificationError
+		recErr   tls.RecordHeaderError
+		authErr  x509.UnknownAuthorityError
+		hostErr  x509.HostnameError
+		validErr x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, netguard.ErrBlocked):
+		return ErrKindBlocked, msg
+	case errors.Is(err, errTooManyRedirects):
+		return ErrKindTooManyRedirects, msg
+	case errors.As(err, &urlErr) && urlErr.Op == "parse",
+		strings.Contains(msg, "unsupported protocol scheme"),
+		strings.Contains(msg, "no Host in request URL"):
+		return ErrKindInvalidURL, msg
+	case errors.As(err, &dnsErr):
+		return ErrKindDNS, msg
+	case errors.As(err, &certErr), errors.As(err, &recErr), errors.As(err, &authErr),
+		errors.As(err, &hostErr), errors.As(err, &validErr),
+		strings.Contains(msg, "tls: "):
+		return ErrKindTLS, msg
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrKindTimeout, msg
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrKindRefused, msg
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrKindReset, msg
+	default:
+		return ErrKindOther, msg
+	}
+}
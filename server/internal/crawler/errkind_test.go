@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	// a port that was just closed refuses connections
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := "http://" + ln.Addr().String()
+	ln.Close()
+
+	loop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer loop.Close()
+
+	get := func(u string) error {
+		req, err := newRequest(context.Background(), http.MethodGet, u)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{&net.DNSError{Err: "no such host", Name: "nope.invalid", IsNotFound: true}, ErrKindDNS},
+		{get(closed), ErrKindRefused},
+		{get(loop.URL + "/"), ErrKindTooManyRedirects},
+		{get("http://%zz"), ErrKindInvalidURL},
+		{get("mailto:someone@example.com"), ErrKindInvalidURL},
+		{fmt.Errorf("odd"), ErrKindOther},
+	}
+	for _, c := range cases {
+		if got, _ := classify(c.err); got != c.want {
+			t.Errorf("classify(%v) = %q; want %q", c.err, got, c.want)
+		}
+	}
+}
+
+func TestRedirectToMetadataIsBlocked(t *testing.T) {
+	// the test server is allowed (TestMain); its redirect to metadata is not
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	req, _ := newRequest(context.Background(), http.MethodGet, srv.URL+"/")
+	res, err := client.Do(req)
+	if err == nil {
+		res.Body.Close()
+	}
+	if kind, _ := classify(err); kind != ErrKindBlocked {
+		t.Fatalf("classify(%v) = %q; want %q", err, kind, ErrKindBlocked)
+	}
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimits bounds how hard a single host is hit.
+type hostLimits struct {
+	Concurrency int           // max requests in flight per host
+	Interval    time.Duration // min gap between request starts per host
+}
+
+// limitsFor returns the limits of a crawl: the URL's overrides, else the
+// configured defaults.
+func limitsFor(concurrency, intervalMs int) hostLimits {
+	lim := hostLimits{Concurrency: cfg.HostConcurrency, Interval: cfg.HostInterval}
+	if concurrency > 0 {
+		lim.Concurrency = concurrency
+	}
+	if intervalMs > 0 {
+		lim.Interval = time.Duration(intervalMs) * time.Millisecond
+	}
+	return lim
+}
+
+// hostLimiter is shared by all workers so that every page fetch and link
+// check against the same host is throttled together. The robots.txt
+// Crawl-delay raises the interval when it is longer.
+type hostLimiter struct {
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+type hostState struct {
+	active int
+	next   time.Time     // earliest start of the next request
+	freed  chan struct{} // closed (and replaced) whenever a slot frees up
+}
+
+var limiter = &hostLimiter{hosts: map[string]*hostState{}}
+
+// acquire blocks until u's host has a free slot and its interval has
+// passed, then returns the func that releases the slot. onWait, if not
+// nil, is told how long the call is about to sleep for the interval.
+func (l *hostLimiter) acquire(ctx context.Context, u string, lim hostLimits,
+	onWait func(time.Duration)) (func(), error) {
+
+	key := hostKey(u)
+	interval := max(lim.Interval, robots.Delay(ctx, u))
+
+	for {
+		l.mu.Lock()
+		s, ok := l.hosts[key]
+		if !ok {
+			s = &hostState{freed: make(chan struct{})}
+			l.hosts[key] = s
+		}
+		if s.active < max(lim.Concurrency, 1) {
+			now := time.Now()
+			start := now
+			if s.next.After(now) {
+				start = s.next
+			}
+			s.next = start.Add(interval)
+			s.active++
+			l.mu.Unlock()
+
+			release := func() { l.release(key) }
+			if d := start.Sub(now); d > 0 {
+				if onWait != nil {
+					onWait(d)
+				}
+				select {
+				case <-time.After(d):
+				case <-ctx.Done():
+					release()
+					return nil, ctx.Err()
+				}
+			}
+			return release, nil
+		}
+		freed := s.freed
+		l.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (l *hostLimiter) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.hosts[key]
+	s.active--
+	close(s.freed)
+	s.freed = make(chan struct{})
+	// forget idle hosts whose interval has passed
+	if s.active == 0 && time.Now().After(s.next) {
+		delete(l.hosts, key)
+	}
+}
+
+// hostKey groups URLs by host name regardless of scheme and port.
+func hostKey(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return strings.ToLower(parsed.Hostname())
+}
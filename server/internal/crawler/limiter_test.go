@@ -0,0 +1,39 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter(t *testing.T) {
+	l := &hostLimiter{hosts: map[string]*hostState{}}
+	ctx := context.Background()
+	lim := hostLimits{Concurrency: 1, Interval: 30 * time.Millisecond}
+	u := "mailto:x@limiter.test" // not http(s): no robots.txt lookup
+
+	start := time.Now()
+	var waits []time.Duration
+	for i := 0; i < 3; i++ {
+		release, err := l.acquire(ctx, u, lim, func(d time.Duration) { waits = append(waits, d) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if el := time.Since(start); el < 60*time.Millisecond {
+		t.Fatalf("3 requests took %v; want ≥ 2 intervals", el)
+	}
+	if len(waits) != 2 {
+		t.Fatalf("reported %d waits; want 2", len(waits))
+	}
+
+	// a second request must wait for the slot held by the first
+	release, _ := l.acquire(ctx, u, hostLimits{Concurrency: 1}, nil)
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(short, u, hostLimits{Concurrency: 1}, nil); err == nil {
+		t.Fatal("acquired a second slot with concurrency 1")
+	}
+	release()
+}
@@ -0,0 +1,153 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// linkCheck is the outcome of checking one href.
+type linkCheck struct {
+	status   int    // final HTTP status, 0 on network error
+	method   string // HEAD, or GET once the server rejected HEAD
+	attempts int    // requests made, fallback and retries included
+	errKind  string // ErrKind* when status is 0
+	errMsg   string
+	trace    *redirectTrace // of the last attempt
+}
+
+// checkPolicy controls retries of transient link-check failures.
+type checkPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration // doubled after every retry
+	MaxBackoff  time.Duration // also caps Retry-After
+}
+
+var policy = checkPolicy{
+	MaxAttempts: 4,
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// checkURL applies the link-check policy: HEAD first, then a one-byte
+// ranged GET if the server rejects HEAD; transient network errors, 429
+// and 503 are retried with exponential backoff, honouring Retry-After.
+// A retry that would overrun the crawl deadline is skipped and the last
+// result kept.
+func (j *crawlJob) checkURL(ctx context.Context, u string) linkCheck {
+	c := linkCheck{method: http.MethodHead}
+	for retries := 0; ; retries++ {
+		c.attempts++
+		a := j.request(ctx, c.method, u)
+		if a.err == nil && c.method == http.MethodHead && headRejected(a.status) {
+			c.method = http.MethodGet
+			c.attempts++
+			a = j.request(ctx, c.method, u)
+		}
+		c.status = a.status
+		c.trace = a.trace
+		c.errKind, c.errMsg = classify(a.err)
+
+		if !retryable(c.status, c.errKind) || c.attempts >= policy.MaxAttempts || ctx.Err() != nil {
+			return c
+		}
+
+		wait := policy.backoff(retries+1, a.retryAfter)
+		if dl, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(dl) {
+			return c
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return c
+		}
+	}
+}
+
+// attempt is the outcome of a single check request.
+type attempt struct {
+	status     int
+	retryAfter time.Duration
+	trace      *redirectTrace
+	err        error
+}
+
+// request sends one check through the host limiter. GET asks for a
+// single byte and never reads the body.
+func (j *crawlJob) request(ctx context.Context, method, u string) attempt {
+	ctx, trace := withTrace(ctx)
+	a := attempt{trace: trace}
+
+	release, err := limiter.acquire(ctx, u, j.limits, j.prog.wait)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	defer release()
+
+	req, err := newRequest(ctx, method, u)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		a.err = err
+		return a
+	}
+	io.CopyN(io.Discard, res.Body, 1<<10) // lets small bodies reuse the connection
+	res.Body.Close()
+	trace.finish(res)
+
+	a.status = res.StatusCode
+	a.retryAfter = retryAfter(res.Header.Get("Retry-After"))
+	return a
+}
+
+// headRejected reports statuses servers use when they don't support HEAD.
+func headRejected(status int) bool {
+	return status == http.StatusMethodNotAllowed ||
+		status == http.StatusForbidden ||
+		status == http.StatusNotImplemented
+}
+
+// retryable reports transient failures. DNS, TLS, redirect, URL and
+// unclassified errors won't fix themselves within a crawl and are not
+// retried.
+func retryable(status int, errKind string) bool {
+	switch errKind {
+	case ErrKindTimeout, ErrKindRefused, ErrKindReset:
+		return true
+	}
+	return status == http.StatusTooManyRequests ||
+		status == http.StatusServiceUnavailable
+}
+
+// backoff returns the delay before retry number attempt: Retry-After if
+// the server sent one, else BaseBackoff·2^(attempt-1); both capped.
+func (p checkPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	d := retryAfter
+	if d <= 0 {
+		d = p.BaseBackoff << (attempt - 1)
+	}
+	return min(d, p.MaxBackoff)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// linkResult is the shared outcome of checking one href; concurrent
+// checks of the same href wait on done instead of repeating the request.
+type linkResult struct {
+	done  chan struct{}
+	check linkCheck
+	ok    bool // false when the check was cut short by the deadline
+}
+
+// checkLinks fills in CheckStatus and HTTPStatus of every row using up to
+// cfg.LinkWorkers concurrent checks. progress receives each finished row
+// and the number of finished rows, which only ever grows. Rows that could not be checked
+// before ctx ended are marked "unchecked" rather than dropped.
+func (j *crawlJob) checkLinks(ctx context.Context, rows []models.Link, progress func(row *models.Link, done int)) {
+	idx := make(chan int)
+	var finished atomic.Int64
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(cfg.LinkWorkers, len(rows)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				j.checkLink(ctx, &rows[i])
+				progress(&rows[i], int(finished.Add(1)))
+			}
+		}()
+	}
+
+feed:
+	for i := range rows {
+		select {
+		case idx <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(idx)
+	wg.Wait()
+
+	for i := range rows {
+		if rows[i].CheckStatus == "" {
+			rows[i].CheckStatus = "unchecked"
+		}
+	}
+}
+
+// checkLink resolves one row; it leaves CheckStatus empty if the
+// deadline fired before a result was known.
+func (j *crawlJob) checkLink(ctx context.Context, row *models.Link) {
+	if ctx.Err() != nil {
+		return
+	}
+	if !robots.Allowed(ctx, row.Href) {
+		row.CheckStatus = "blocked_by_robots"
+		return
+	}
+	c, ok := j.status(ctx, row.Href)
+	if !ok {
+		return
+	}
+	row.HTTPStatus = &c.status
+	row.Method = c.method
+	row.Attempts = c.attempts
+	row.ErrorKind = c.errKind
+	row.ErrorMessage = c.errMsg
+	if t := c.trace; t != nil && len(t.hops) > 0 {
+		// copies, since duplicate hrefs share one trace
+		row.Redirects = append([]models.Redirect(nil), t.hops...)
+		for i := range row.Redirects {
+			row.Redirects[i].URLID = row.URLID
+			row.Redirects[i].RunID = row.RunID
+		}
+		row.RedirectLoop = t.loop
+		row.LongRedirect = t.long()
+	}
+	row.CheckStatus = "checked"
+}
+
+// status returns the cached check of href, checking it at most once per
+// crawl.
+func (j *crawlJob) status(ctx context.Context, href string) (linkCheck, bool) {
+	j.mu.Lock()
+	r, found := j.statuses[href]
+	if !found {
+		r = &linkResult{done: make(chan struct{})}
+		j.statuses[href] = r
+	}
+	j.mu.Unlock()
+
+	if found {
+		select {
+		case <-r.done:
+			return r.check, r.ok
+		case <-ctx.Done():
+			return linkCheck{}, false
+		}
+	}
+
+	r.check = j.checkURL(ctx, href)
+	r.ok = r.check.status != 0 || ctx.Err() == nil
+	close(r.done)
+	return r.check, r.ok
+}
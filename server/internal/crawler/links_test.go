@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+func TestCheckLinksMarksUncheckedOnDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer srv.Close()
+
+	rows := []models.Link{
+		{Href: srv.URL + "/fast"},
+		{Href: srv.URL + "/slow"},
+		{Href: srv.URL + "/fast"}, // duplicate shares the first result
+		{Href: srv.URL + "/slow"},
+	}
+	j := &crawlJob{limits: limitsFor(0, 0), prog: &tracker{}, statuses: map[string]*linkResult{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	var last atomic.Int64
+	j.checkLinks(ctx, rows, func(_ *models.Link, done int) { last.Store(max(last.Load(), int64(done))) })
+
+	want := []string{"checked", "unchecked", "checked", "unchecked"}
+	for i, row := range rows {
+		if row.CheckStatus != want[i] {
+			t.Errorf("row %d (%s): %q; want %q", i, row.Href, row.CheckStatus, want[i])
+		}
+	}
+	if *rows[0].HTTPStatus != http.StatusOK {
+		t.Errorf("fast link status = %d", *rows[0].HTTPStatus)
+	}
+	if n := last.Load(); n != int64(len(rows)) {
+		t.Errorf("progress ended at %d; want %d", n, len(rows))
+	}
+}
+
+func TestCheckURLPolicy(t *testing.T) {
+	saved := policy
+	policy.BaseBackoff = time.Millisecond
+	defer func() { policy = saved }()
+
+	var flaky atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/flaky":
+			if flaky.Add(1) < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/down":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	j := &crawlJob{limits: limitsFor(0, 0), prog: &tracker{}}
+	cases := []struct {
+		path string
+		want linkCheck
+	}{
+		{"/ok", linkCheck{status: 200, method: "HEAD", attempts: 1}},
+		{"/no-head", linkCheck{status: 200, method: "GET", attempts: 2}},
+		{"/flaky", linkCheck{status: 200, method: "HEAD", attempts: 3}},
+		{"/down", linkCheck{status: 503, method: "HEAD", attempts: policy.MaxAttempts}},
+	}
+	for _, c := range cases {
+		got := j.checkURL(context.Background(), srv.URL+c.path)
+		got.trace = nil
+		if got != c.want {
+			t.Errorf("%s: got %+v; want %+v", c.path, got, c.want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	for _, c := range []struct {
+		status int
+		kind   string
+		want   bool
+	}{
+		{0, ErrKindTimeout, true},
+		{0, ErrKindRefused, true},
+		{0, ErrKindReset, true},
+		{0, ErrKindDNS, false},
+		{0, ErrKindOther, false},
+		{http.StatusTooManyRequests, "", true},
+		{http.StatusServiceUnavailable, "", true},
+		{http.StatusInternalServerError, "", false},
+	} {
+		if got := retryable(c.status, c.kind); got != c.want {
+			t.Errorf("retryable(%d, %q) = %v; want %v", c.status, c.kind, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package crawler
+
+import (
+	"os"
+	"testing"
+)
+
+// The tests crawl httptest servers on loopback, which the SSRF guard
+// refuses unless allowed. Setting the default keeps it across Init calls.
+func TestMain(m *testing.M) {
+	defaultConfig.AllowHosts = []string{"127.0.0.1"}
+	Init(Config{})
+	os.Exit(m.Run())
+}
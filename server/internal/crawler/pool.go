@@ -0,0 +1,245 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+/*──────────────── worker pool ────────────────
+ * A Pool owns the goroutines that claim and crawl queued jobs. It can be
+ * resized while running: new workers start claiming at once, surplus
+ * workers stop claiming and exit after their current crawl.
+ *──────────────────────────────────────────────*/
+
+// Workers is the pool main() starts; the admin API inspects and resizes it.
+var Workers *Pool
+
+// MaxWorkers bounds Resize so a typo cannot start thousands of crawls.
+const MaxWorkers = 256
+
+var errPoolClosed = errors.New("worker pool is shut down")
+
+// Pool runs a resizable set of workers.
+type Pool struct {
+	mu      sync.Mutex
+	workers []*poolWorker // in start order, including ones still stopping
+	seq     int           // numbers worker ids
+	closed  bool
+
+	wg sync.WaitGroup
+}
+
+type poolWorker struct {
+	id    string
+	ctx   context.Context
+	stop  context.CancelFunc
+	mu    sync.Mutex
+	job   *models.Job // nil while idle
+	since time.Time   // start of the current state
+}
+
+// WorkerState describes one worker for the admin API.
+type WorkerState struct {
+	ID       string    `json:"id"`
+	State    string    `json:"state"`    // idle | crawling
+	Stopping bool      `json:"stopping"` // exits after the current crawl
+	JobID    uint64    `json:"job_id,omitempty"`
+	URLID    uint64    `json:"url_id,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// NewPool starts size workers; size <= 0 means Config.Workers.
+func NewPool(size int) *Pool {
+	if size <= 0 {
+		size = cfg.Workers
+	}
+	p := &Pool{}
+	p.Resize(size)
+	return p
+}
+
+// Resize starts or stops workers until n are claiming jobs. Stopped
+// workers finish the crawl they are on before exiting.
+func (p *Pool) Resize(n int) error {
+	if n < 0 || n > MaxWorkers {
+		return errors.New("pool size out of range")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return errPoolClosed
+	}
+
+	var active []*poolWorker
+	for _, w := range p.workers {
+		if w.ctx.Err() == nil {
+			active = append(active, w)
+		}
+	}
+	for i := len(active) - 1; i >= n; i-- { // newest go first
+		active[i].stop()
+	}
+	for i := len(active); i < n; i++ {
+		ctx, stop := context.WithCancel(context.Background())
+		w := &poolWorker{id: WorkerID(p.seq), ctx: ctx, stop: stop, since: time.Now()}
+		p.seq++
+		p.workers = append(p.workers, w)
+		p.wg.Add(1)
+		go p.run(w)
+	}
+	return nil
+}
+
+// Size is the number of workers claiming jobs (stopping ones excluded).
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, w := range p.workers {
+		if w.ctx.Err() == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// States reports every worker, including those still stopping.
+func (p *Pool) States() []WorkerState {
+	p.mu.Lock()
+	ws := append([]*poolWorker(nil), p.workers...)
+	p.mu.Unlock()
+
+	out := make([]WorkerState, len(ws))
+	for i, w := range ws {
+		w.mu.Lock()
+		out[i] = WorkerState{ID: w.id, State: "idle", Stopping: w.ctx.Err() != nil, Since: w.since}
+		if w.job != nil {
+			out[i].State, out[i].JobID, out[i].URLID = "crawling", w.job.ID, w.job.URLID
+		}
+		w.mu.Unlock()
+	}
+	return out
+}
+
+// Shutdown stops all workers and waits for running crawls to finish.
+// If ctx ends first, those crawls are cancelled, their jobs go back to
+// the queue for the next start, and ctx.Err() is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	for _, w := range p.workers {
+		w.stop()
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	for _, s := range p.States() {
+		if s.URLID != 0 {
+			cancelLocal(s.URLID, errShutdown)
+		}
+	}
+	<-done // cancelled crawls return promptly
+	return ctx.Err()
+}
+
+// run is one worker's claim loop.
+func (p *Pool) run(w *poolWorker) {
+	defer p.wg.Done()
+	defer p.remove(w)
+
+	for w.ctx.Err() == nil {
+		job, err := claim(w.id)
+		if err != nil {
+			log.Println("claim:", err)
+		}
+		if job == nil {
+			select {
+			case <-w.ctx.Done():
+			case <-wake:
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		w.set(job)
+		p.runJob(job, w.id)
+		w.set(nil)
+	}
+}
+
+// runJob crawls the job's URL while keeping its lease alive and watching
+// for a stop request. A crawl cut short by Shutdown hands the job back;
+// one whose lease was lost leaves it to the worker that took it over.
+func (p *Pool) runJob(job *models.Job, worker string) {
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	var lost atomic.Bool
+	go func() {
+		defer close(stopped)
+		lease := time.NewTicker(leaseTTL / 3)
+		defer lease.Stop()
+		check := time.NewTicker(cancelPoll) // durable backup for broker.Cancel
+		defer check.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-lease.C:
+				if !renew(job, worker) {
+					log.Printf("job %d: lease lost", job.ID)
+					lost.Store(true)
+					cancelLocal(job.URLID, errLeaseLost)
+					return
+				}
+			case <-check.C:
+				if cancelRequested(job.URLID) {
+					cancelLocal(job.URLID, errCancelled)
+				}
+			}
+		}
+	}()
+
+	interrupted := crawl(job.URLID)
+	close(stop)
+	<-stopped // no renew may follow complete or release
+	switch {
+	case lost.Load():
+	case interrupted:
+		release(job, worker)
+	default:
+		complete(job, worker)
+	}
+}
+
+func (p *Pool) remove(w *poolWorker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, x := range p.workers {
+		if x == w {
+			p.workers = append(p.workers[:i], p.workers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (w *poolWorker) set(job *models.Job) {
+	w.mu.Lock()
+	w.job, w.since = job, time.Now()
+	w.mu.Unlock()
+}
@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+// waitFor polls cond for up to two seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for end := time.Now().Add(2 * time.Second); time.Now().Before(end); time.Sleep(10 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestPoolResize(t *testing.T) {
+	test.InitInMemoryDB()
+	p := NewPool(2)
+
+	if err := p.Resize(4); err != nil || p.Size() != 4 {
+		t.Fatalf("Resize(4): size %d, %v", p.Size(), err)
+	}
+	if err := p.Resize(1); err != nil || p.Size() != 1 {
+		t.Fatalf("Resize(1): size %d, %v", p.Size(), err)
+	}
+	waitFor(t, "stopped workers to exit", func() bool { return len(p.States()) == 1 })
+	if err := p.Resize(MaxWorkers + 1); err == nil {
+		t.Fatal("oversized Resize accepted")
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Resize(1); !errors.Is(err, errPoolClosed) {
+		t.Fatalf("Resize after Shutdown = %v", err)
+	}
+}
+
+func TestPoolShutdownDeadlineRequeues(t *testing.T) {
+	test.InitInMemoryDB()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		<-r.Context().Done() // hang until the crawl is cancelled
+	}))
+	defer srv.Close()
+
+	u := models.URL{OriginalURL: srv.URL + "/", UserID: 1}
+	database.DB.Create(&u)
+	database.DB.Create(&models.Webhook{UserID: 1, URL: "http://hooks.example", Secret: "s", Events: "done,error"})
+	database.DB.Create(&models.AlertRule{UserID: 1, Metric: "broken_links", Op: ">=", Threshold: 0})
+	Enqueue(PriorityInteractive, u.ID)
+
+	p := NewPool(1)
+	waitFor(t, "crawl to start", func() bool { return p.States()[0].State == "crawling" })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v; want deadline exceeded", err)
+	}
+
+	var j models.Job
+	database.DB.First(&j)
+	if j.State != "queued" || j.Attempts != 0 || j.WorkerID != nil {
+		t.Fatalf("job after aborted shutdown = %+v; want queued again", j)
+	}
+
+	// the crawl did not end: no finished run, no webhook, no alert
+	var runs []models.CrawlRun
+	database.DB.Find(&runs)
+	if len(runs) != 1 || runs[0].Status != "interrupted" {
+		t.Fatalf("runs after aborted shutdown = %+v; want one interrupted", runs)
+	}
+	database.DB.First(&u, u.ID)
+	if u.CrawlStatus != "queued" {
+		t.Fatalf("crawl_status = %q; want queued", u.CrawlStatus)
+	}
+	var deliveries, alerts int64
+	database.DB.Model(&models.WebhookDelivery{}).Count(&deliveries)
+	database.DB.Model(&models.Alert{}).Count(&alerts)
+	if deliveries != 0 || alerts != 0 {
+		t.Fatalf("%d webhook deliveries, %d alerts for an interrupted crawl", deliveries, alerts)
+	}
+}
+
+func TestPoolStopsCrawlOnLostLease(t *testing.T) {
+	test.InitInMemoryDB()
+	defer func(d time.Duration) { leaseTTL = d }(leaseTTL)
+	leaseTTL = 30 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		<-r.Context().Done() // hang until the crawl is cancelled
+	}))
+	defer srv.Close()
+
+	u := models.URL{OriginalURL: srv.URL + "/", UserID: 1}
+	database.DB.Create(&u)
+	Enqueue(PriorityInteractive, u.ID)
+
+	p := NewPool(1)
+	defer p.Shutdown(context.Background())
+	waitFor(t, "crawl to start", func() bool { return p.States()[0].State == "crawling" })
+
+	// another worker took the job over while this one was stalled
+	later := time.Now().Add(time.Hour)
+	database.DB.Model(&models.Job{}).Where("url_id = ?", u.ID).
+		Updates(map[string]any{"worker_id": "other", "lease_until": later})
+	waitFor(t, "crawl to stop", func() bool { return p.States()[0].State != "crawling" })
+
+	var j models.Job
+	database.DB.First(&j)
+	if j.State != "leased" || j.WorkerID == nil || *j.WorkerID != "other" {
+		t.Fatalf("job after lost lease = %+v; want still leased to the other worker", j)
+	}
+	var run models.CrawlRun
+	database.DB.First(&run)
+	if run.Status != "interrupted" {
+		t.Fatalf("run status = %q; want interrupted", run.Status)
+	}
+}
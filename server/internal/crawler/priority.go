@@ -0,0 +1,47 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Priority orders jobs in the queue: workers always take the highest
+// waiting priority first, then share it round-robin between users.
+type Priority int
+
+const (
+	PriorityScheduled   Priority = iota // recurring crawls fired by Scheduler
+	PriorityBulk                        // bulk restarts
+	PriorityInteractive                 // a single URL submitted by hand
+)
+
+var priorityNames = map[Priority]string{
+	PriorityScheduled:   "scheduled",
+	PriorityBulk:        "bulk",
+	PriorityInteractive: "interactive",
+}
+
+func (p Priority) String() string {
+	if s, ok := priorityNames[p]; ok {
+		return s
+	}
+	return fmt.Sprintf("priority(%d)", int(p))
+}
+
+// MarshalJSON writes the API name, e.g. "bulk".
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// ParsePriority maps an API name to a Priority; "" yields def.
+func ParsePriority(s string, def Priority) (Priority, error) {
+	if s == "" {
+		return def, nil
+	}
+	for p, name := range priorityNames {
+		if name == s {
+			return p, nil
+		}
+	}
+	return def, fmt.Errorf("unknown priority %q", s)
+}
@@ -1,48 +1,248 @@
 package crawler
 
-import "sync"
+import (
+	"sync"
+	"time"
 
-type (
-	// Channel on which we send int percentages 0-100.
-	ProgressCh = chan int
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+/*──────────────── crawl events ────────────────
+ * A running crawl publishes a stream of typed events. IDs count up from 1
+ * per crawl in publish order, and every event carries the overall
+ * percentage reached so far, so a client that only draws a progress bar
+ * can read Pct off any of them. done is always the last event.
+ *
+ * Status events stand apart: they are published whenever a URL's
+ * crawl_status changes, carry no ID and only reach SubscribeUser.
+ *──────────────────────────────────────────────*/
+
+// EventType names the kind of an Event; it is also the SSE event name.
+type EventType string
+
+const (
+	EventProgress EventType = "progress" // Pct moved forward
+	EventWait     EventType = "wait"     // the host limiter holds the crawl back for WaitMs
+	EventStage    EventType = "stage"    // Page entered Stage
+	EventLink     EventType = "link"     // one link of Page was checked
+	EventCounts   EventType = "counts"   // running totals after a page
+	EventError    EventType = "error"    // Page could not be crawled
+	EventDone     EventType = "done"     // the crawl ended; Summary holds the result
+	EventStatus   EventType = "status"   // the URL's crawl_status is now Status
+)
+
+// Stage is the step a page crawl is in.
+type Stage string
+
+const (
+	StageFetching Stage = "fetching"
+	StageParsing  Stage = "parsing"
+	StageChecking Stage = "checking_links"
 )
 
-var (
-	mu        sync.RWMutex
-	listeners = make(map[uint64][]ProgressCh) // urlID → fan-out list
+type (
+	// Event is one update of a running crawl. Only the fields belonging
+	// to Type are set, apart from ID, URLID, RunID and Pct.
+	Event struct {
+		ID      uint64     `json:"id"`
+		URLID   uint64     `json:"url_id"`
+		UserID  uint64     `json:"-"` // owner of the URL, for SubscribeUser
+		RunID   uint64     `json:"run_id,omitempty"`
+		Type    EventType  `json:"type"`
+		Pct     int        `json:"pct"` // 0-100
+		Stage   Stage      `json:"stage,omitempty"`
+		Page    string     `json:"page,omitempty"`
+		WaitMs  int64      `json:"wait_ms,omitempty"`
+		Link    *LinkEvent `json:"link,omitempty"`
+		Counts  *Counts    `json:"counts,omitempty"`
+		Error   string     `json:"error,omitempty"`
+		Summary *Summary   `json:"summary,omitempty"`
+		Status  string     `json:"status,omitempty"`
+	}
+
+	// LinkEvent is the result of one link check.
+	LinkEvent struct {
+		Href        string `json:"href"`
+		Internal    bool   `json:"is_internal"`
+		CheckStatus string `json:"check_status"` // checked | blocked_by_robots | unchecked
+		HTTPStatus  *int   `json:"http_status"`
+		ErrorKind   string `json:"error_kind,omitempty"`
+		Redirects   int    `json:"redirects,omitempty"`
+	}
+
+	// Counts are the running totals over the pages finished so far.
+	Counts struct {
+		Pages    int `json:"pages"`
+		Internal int `json:"internal_links"`
+		External int `json:"external_links"`
+		Broken   int `json:"broken_links"`
+	}
+
+	// Summary is the outcome of a crawl, as stored on its run and URL.
+	Summary struct {
+		Status          string  `json:"status"` // done | error | cancelled | interrupted
+		Pages           int     `json:"pages,omitempty"`
+		HTMLVersion     *string `json:"html_version"`
+		Title           *string `json:"title"`
+		H1              int     `json:"h1"`
+		H2              int     `json:"h2"`
+		H3              int     `json:"h3"`
+		InternalLinks   int     `json:"internal_links"`
+		ExternalLinks   int     `json:"external_links"`
+		BrokenLinks     int     `json:"broken_links"`
+		BlockedByRobots int     `json:"blocked_by_robots"`
+		UncheckedLinks  int     `json:"unchecked_links"`
+		RedirectedLinks int     `json:"redirected_links"`
+		HasLogin        bool    `json:"has_login"`
+	}
+
+	// Channel on which we send crawl events.
+	EventCh = chan Event
 )
 
-// Subscribe returns a channel and a cancel func.
-func Subscribe(urlID uint64) (ProgressCh, func()) {
-	ch := make(ProgressCh, 4)
-
-	mu.Lock()
-	listeners[urlID] = append(listeners[urlID], ch)
-	mu.Unlock()
-
-	cancel := func() {
-		mu.Lock()
-		defer mu.Unlock()
-		subs := listeners[urlID]
-		for i, c := range subs {
-			if c == ch {
-				listeners[urlID] = append(subs[:i], subs[i+1:]...)
-				close(c)
-				break
-			}
+// Subscribe returns a channel of events for urlID and a cancel func.
+func Subscribe(urlID uint64) (EventCh, func()) { return broker.Subscribe(urlID) }
+
+// SubscribeUser returns a channel of the events of every crawl owned by
+// userID, status events included, and a cancel func.
+func SubscribeUser(userID uint64) (EventCh, func()) { return broker.SubscribeUser(userID) }
+
+// Publish fan-outs e to every listener of e.URLID; non-blocking.
+func Publish(e Event) { broker.Publish(e) }
+
+// Current returns the last event published by a running crawl.
+func Current(urlID uint64) (Event, bool) { return broker.Last(urlID) }
+
+// Replay returns the logged events of urlID's crawl run with an ID above
+// after, so a reconnecting client can catch up.
+func Replay(urlID, run, after uint64) []Event { return broker.Replay(urlID, run, after) }
+
+// announce publishes a status event with the current crawl_status of
+// each of ids.
+func announce(ids ...uint64) {
+	if len(ids) == 0 {
+		return
+	}
+	var urls []models.URL
+	database.DB.Select("id", "user_id", "crawl_status").Where("id IN ?", ids).Find(&urls)
+	for _, u := range urls {
+		e := Event{URLID: u.ID, UserID: u.UserID, Type: EventStatus, Status: u.CrawlStatus}
+		if u.CrawlStatus == "done" {
+			e.Pct = 100
 		}
+		Publish(e)
 	}
-	return ch, cancel
 }
 
-// Publish fan-outs pct to every listener of urlID; non-blocking.
-func Publish(urlID uint64, pct int) {
-	mu.RLock()
-	defer mu.RUnlock()
-	for _, ch := range listeners[urlID] {
-		select { // don’t block if client is slow
-		case ch <- pct:
-		default:
-		}
+// URLSummary is the Summary of u's latest finished crawl.
+func URLSummary(u *models.URL) Summary {
+	return Summary{
+		Status:      u.CrawlStatus,
+		HTMLVersion: u.HTMLVersion,
+		Title:       u.Title,
+		H1:          u.H1, H2: u.H2, H3: u.H3,
+		InternalLinks:   u.InternalLinks,
+		ExternalLinks:   u.ExternalLinks,
+		BrokenLinks:     u.BrokenLinks,
+		BlockedByRobots: u.BlockedByRobots,
+		UncheckedLinks:  u.UncheckedLinks,
+		RedirectedLinks: u.RedirectedLinks,
+		HasLogin:        u.HasLogin,
 	}
 }
+
+// fields are the columns s is stored in on crawl_runs and urls.
+func (s *Summary) fields() map[string]any {
+	return map[string]any{
+		"html_version": s.HTMLVersion,
+		"title":        s.Title,
+		"h1":           s.H1, "h2": s.H2, "h3": s.H3,
+		"internal_links":    s.InternalLinks,
+		"external_links":    s.ExternalLinks,
+		"broken_links":      s.BrokenLinks,
+		"blocked_by_robots": s.BlockedByRobots,
+		"unchecked_links":   s.UncheckedLinks,
+		"redirected_links":  s.RedirectedLinks,
+		"has_login":         s.HasLogin,
+	}
+}
+
+// tracker publishes the events of one crawl. The number of planned pages
+// grows as links are discovered, so the percentage is clamped to never go
+// backwards and to stay below 100 until done.
+type tracker struct {
+	mu   sync.Mutex
+	id   uint64
+	user uint64
+	run  uint64
+	seq  uint64
+	last int
+}
+
+// emit stamps e with the next ID and the current percentage and
+// publishes it. Holding mu while publishing keeps IDs in delivery order.
+func (t *tracker) emit(e Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.send(e)
+}
+
+func (t *tracker) send(e Event) {
+	t.seq++
+	e.ID, e.URLID, e.UserID, e.RunID, e.Pct = t.seq, t.id, t.user, t.run, t.last
+	Publish(e)
+}
+
+// start announces a new crawl at 0 %.
+func (t *tracker) start() { t.emit(Event{Type: EventProgress}) }
+
+// page reports that done pages of planned are finished and the current
+// one is frac (0–1) complete.
+func (t *tracker) page(done, planned int, frac float64) {
+	pct := min(int((float64(done)+frac)/float64(planned)*100), 99)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if pct > t.last {
+		t.last = pct
+		t.send(Event{Type: EventProgress})
+	}
+}
+
+// wait reports that the next request is held back for d by the limiter.
+func (t *tracker) wait(d time.Duration) {
+	t.emit(Event{Type: EventWait, WaitMs: d.Milliseconds()})
+}
+
+// stage reports that page moved on to s.
+func (t *tracker) stage(page string, s Stage) {
+	t.emit(Event{Type: EventStage, Page: page, Stage: s})
+}
+
+// link reports the checked row of page.
+func (t *tracker) link(page string, row *models.Link) {
+	t.emit(Event{Type: EventLink, Page: page, Link: &LinkEvent{
+		Href:        row.Href,
+		Internal:    row.IsInternal,
+		CheckStatus: row.CheckStatus,
+		HTTPStatus:  row.HTTPStatus,
+		ErrorKind:   row.ErrorKind,
+		Redirects:   len(row.Redirects),
+	}})
+}
+
+// counts reports the running totals.
+func (t *tracker) counts(c Counts) { t.emit(Event{Type: EventCounts, Counts: &c}) }
+
+// fail reports that page could not be crawled.
+func (t *tracker) fail(page string, err error) {
+	t.emit(Event{Type: EventError, Page: page, Error: err.Error()})
+}
+
+// done ends the stream with the crawl's outcome.
+func (t *tracker) done(s Summary) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = 100
+	t.send(Event{Type: EventDone, Summary: &s})
+}
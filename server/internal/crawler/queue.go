@@ -1,7 +1,336 @@
 package crawler
 
-// Jobs is a global buffered channel; capacity = 100 URLs
-var Jobs = make(chan uint64, 100)
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
 
-// CloseQueue lets main() shut workers down gracefully
-func CloseQueue() { close(Jobs) }
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*──────────────── durable job queue ────────────────
+ * Jobs live in the jobs table so a restart loses nothing. A worker claims
+ * the oldest queued job by leasing it (worker_id + lease_until) and renews
+ * the lease while crawling; a lease that runs out means the worker died,
+ * and the job becomes claimable again until maxAttempts is reached.
+ *───────────────────────────────────────────────────*/
+
+var (
+	leaseTTL     = 2 * time.Minute // renewed every leaseTTL/3 while crawling
+	pollInterval = time.Second     // idle workers re-check the table this often
+	maxAttempts  = 3               // claims per job before it is failed
+)
+
+// wake nudges one idle worker after Enqueue so new jobs start without
+// waiting for the next poll.
+var wake = make(chan struct{}, 1)
+
+var errLeaseExhausted = errors.New("lease expired too many times")
+
+// ErrQueueFull is returned by Enqueue when accepting the ids would push
+// the number of waiting jobs past Config.QueueCapacity.
+var ErrQueueFull = errors.New("crawl queue is full")
+
+// Ticket tells a producer where a URL's job sits in the queue.
+type Ticket struct {
+	URLID    uint64   `json:"url_id"`
+	JobID    uint64   `json:"job_id"`
+	Priority Priority `json:"priority"`
+	Position int64    `json:"position"` // 1 = next to be claimed, 0 = already running
+}
+
+// Enqueue queues a crawl at priority p for every id and marks the URLs
+// queued. An id that already has a queued or leased job keeps it, raised
+// to p if that is higher; ids without a URL row are ignored. Either all
+// ids are accepted or, with ErrQueueFull or a database error, none are.
+// Enqueue never waits for a worker; it is the producer API used by
+// handlers and the scheduler.
+func Enqueue(p Priority, ids ...uint64) ([]Ticket, error) {
+	var jobs []models.Job
+	var queued []uint64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// concurrent Enqueues would each count the same free room
+		if err := lockQueue(tx); err != nil {
+			return err
+		}
+
+		var urls []models.URL
+		if err := tx.Select("id", "user_id").Where("id IN ?", ids).
+			Find(&urls).Error; err != nil {
+			return err
+		}
+
+		var active []models.Job
+		if err := tx.
+			Where("url_id IN ? AND state IN ?", ids, []string{"queued", "leased"}).
+			Find(&active).Error; err != nil {
+			return err
+		}
+		have := map[uint64]bool{}
+		for i, j := range active {
+			have[j.URLID] = true
+			if j.State == "queued" && j.Priority < int(p) {
+				if err := tx.Model(&active[i]).Update("priority", int(p)).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		var fresh []models.Job
+		for _, u := range urls {
+			if !have[u.ID] {
+				have[u.ID] = true
+				fresh = append(fresh, models.Job{
+					URLID: u.ID, UserID: u.UserID, Priority: int(p), State: "queued",
+				})
+			}
+		}
+		jobs = active
+		if len(fresh) == 0 {
+			return nil
+		}
+
+		var depth int64
+		if err := tx.Model(&models.Job{}).Where("state = ?", "queued").
+			Count(&depth).Error; err != nil {
+			return err
+		}
+		if depth+int64(len(fresh)) > int64(cfg.QueueCapacity) {
+			return ErrQueueFull
+		}
+
+		if err := tx.Create(&fresh).Error; err != nil {
+			return err
+		}
+		urlIDs := make([]uint64, len(fresh))
+		for i, j := range fresh {
+			urlIDs[i] = j.URLID
+		}
+		if err := tx.Model(&models.URL{}).Where("id IN ?", urlIDs).
+			Update("crawl_status", "queued").Error; err != nil {
+			return err
+		}
+		jobs, queued = append(jobs, fresh...), urlIDs
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	announce(queued...)
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+
+	tickets := make([]Ticket, len(jobs))
+	for i, j := range jobs {
+		tickets[i] = Ticket{URLID: j.URLID, JobID: j.ID, Priority: Priority(j.Priority)}
+		if j.State == "queued" {
+			tickets[i].Position = position(&j)
+		}
+	}
+	return tickets, nil
+}
+
+// settingQueueLock names the settings row Enqueue locks so only one
+// transaction at a time counts the queue and adds to it.
+const settingQueueLock = "queue_lock"
+
+// lockQueue takes the queue lock for the rest of tx, creating the row
+// the first time (migrations seed it).
+func lockQueue(tx *gorm.DB) error {
+	for created := false; ; created = true {
+		var st models.Setting
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(&models.Setting{Key: settingQueueLock}).Limit(1).Find(&st)
+		if res.Error != nil || res.RowsAffected == 1 || created {
+			return res.Error
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Setting{Key: settingQueueLock}).Error; err != nil {
+			return err
+		}
+	}
+}
+
+// position counts the queued jobs ordered at or before j by priority and
+// age. Round-robin between users can only move j forward, so this is an
+// upper bound.
+func position(j *models.Job) int64 {
+	var n int64
+	database.DB.Model(&models.Job{}).
+		Where("state = ?", "queued").
+		Where("priority > ? OR (priority = ? AND id <= ?)", j.Priority, j.Priority, j.ID).
+		Count(&n)
+	return n
+}
+
+// QueueDepth is the number of jobs waiting for a worker.
+func QueueDepth() (int64, error) {
+	var n int64
+	err := database.DB.Model(&models.Job{}).Where("state = ?", "queued").Count(&n).Error
+	return n, err
+}
+
+// Recover re-queues jobs whose lease has expired, fails those that have
+// used up their attempts and cancels those that were asked to stop.
+// main() calls it once before starting workers so crawls interrupted by
+// a restart are picked up again.
+func Recover() error {
+	now := time.Now()
+	var stale []models.Job
+	if err := database.DB.
+		Where("state = ? AND lease_until < ?", "leased", now).
+		Find(&stale).Error; err != nil {
+		return err
+	}
+
+	ids := make([]uint64, len(stale))
+	for i, j := range stale {
+		ids[i] = j.URLID
+		runStatus := "error"
+		switch {
+		case j.CancelRequestedAt != nil: // asked to stop before it died
+			runStatus = "cancelled"
+			database.DB.Model(&j).Updates(map[string]any{"state": "cancelled", "lease_until": nil})
+			database.DB.Model(&models.URL{}).Where("id = ?", j.URLID).
+				Update("crawl_status", "cancelled")
+		case j.Attempts >= maxAttempts:
+			failJob(database.DB, &j, errLeaseExhausted)
+		default:
+			database.DB.Model(&j).Updates(map[string]any{
+				"state": "queued", "worker_id": nil, "lease_until": nil,
+			})
+			database.DB.Model(&models.URL{}).Where("id = ?", j.URLID).
+				Update("crawl_status", "queued")
+		}
+		closeRuns(database.DB, j.URLID, runStatus, now)
+	}
+	announce(ids...)
+	return nil
+}
+
+// WorkerID names worker n of this process, e.g. "api-7f9c-1234-3".
+func WorkerID(n int) string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), n)
+}
+
+// claim leases the next claimable job to worker. It returns nil, nil
+// when the queue is empty or paused.
+func claim(worker string) (*models.Job, error) {
+	if paused, err := QueuePaused(); paused || err != nil {
+		return nil, err
+	}
+
+	var job, failed *models.Job
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var j models.Job
+		// highest priority first; within it, the user served least
+		// recently (never served first), then that user's oldest job
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("state = ? OR (state = ? AND lease_until < ? AND cancel_requested_at IS NULL)",
+				"queued", "leased", now).
+			Order("priority DESC").
+			Order("(SELECT MAX(k.claimed_at) FROM jobs k WHERE k.user_id = jobs.user_id) IS NOT NULL").
+			Order("(SELECT MAX(k.claimed_at) FROM jobs k WHERE k.user_id = jobs.user_id)").
+			Order("id").Limit(1).Find(&j)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		if j.State == "leased" { // taking over from a dead worker
+			if err := closeRuns(tx, j.URLID, "error", now); err != nil {
+				return err
+			}
+		}
+		if j.Attempts >= maxAttempts { // a dead worker held it last time
+			failed = &j
+			return failJob(tx, &j, errLeaseExhausted)
+		}
+
+		// every claim bumps attempts, so this guard also covers databases
+		// without row locks (sqlite)
+		upd := tx.Model(&models.Job{}).
+			Where("id = ? AND attempts = ?", j.ID, j.Attempts).
+			Updates(map[string]any{
+				"state":       "leased",
+				"attempts":    j.Attempts + 1,
+				"worker_id":   worker,
+				"claimed_at":  now,
+				"lease_until": now.Add(leaseTTL),
+			})
+		if upd.Error != nil || upd.RowsAffected == 0 {
+			return upd.Error
+		}
+		j.State, j.Attempts, j.WorkerID = "leased", j.Attempts+1, &worker
+		job = &j
+		return nil
+	})
+	if failed != nil && err == nil {
+		announce(failed.URLID)
+	}
+	return job, err
+}
+
+// renew extends the lease; false means another worker has taken the job.
+func renew(job *models.Job, worker string) bool {
+	res := database.DB.Model(&models.Job{}).
+		Where("id = ? AND worker_id = ? AND state = ?", job.ID, worker, "leased").
+		Update("lease_until", time.Now().Add(leaseTTL))
+	return res.Error == nil && res.RowsAffected == 1
+}
+
+// complete marks a leased job done, or cancelled if it was asked to
+// stop, provided worker still holds it.
+func complete(job *models.Job, worker string) {
+	database.DB.Model(&models.Job{}).
+		Where("id = ? AND worker_id = ? AND state = ?", job.ID, worker, "leased").
+		Updates(map[string]any{
+			"state":       gorm.Expr("CASE WHEN cancel_requested_at IS NULL THEN 'done' ELSE 'cancelled' END"),
+			"lease_until": nil,
+		})
+}
+
+// release hands a leased job back to the queue without using up an
+// attempt, e.g. when shutdown interrupts its crawl.
+func release(job *models.Job, worker string) {
+	res := database.DB.Model(&models.Job{}).
+		Where("id = ? AND worker_id = ? AND state = ?", job.ID, worker, "leased").
+		Updates(map[string]any{
+			"state": "queued", "attempts": gorm.Expr("attempts - 1"),
+			"worker_id": nil, "lease_until": nil,
+		})
+	if res.Error == nil && res.RowsAffected == 1 {
+		database.DB.Model(&models.URL{}).Where("id = ?", job.URLID).
+			Update("crawl_status", "queued")
+		announce(job.URLID)
+	}
+}
+
+// closeRuns ends the runs of urlID left "running" by a worker that died:
+// they will never finish on their own.
+func closeRuns(db *gorm.DB, urlID uint64, status string, now time.Time) error {
+	return db.Model(&models.CrawlRun{}).
+		Where("url_id = ? AND status = ?", urlID, "running").
+		Updates(map[string]any{"status": status, "finished_at": now}).Error
+}
+
+// failJob gives up on a job and marks its URL as errored. Callers
+// announce the change once db is committed.
+func failJob(db *gorm.DB, job *models.Job, cause error) error {
+	msg := cause.Error()
+	if err := db.Model(job).Updates(map[string]any{
+		"state": "failed", "lease_until": nil, "last_error": msg,
+	}).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.URL{}).Where("id = ?", job.URLID).
+		Update("crawl_status", "error").Error
+}
@@ -0,0 +1,176 @@
+package crawler
+
+import (
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*──────────────── queue inspection & control ────────────────*/
+
+const settingQueuePaused = "queue_paused"
+
+// QueueStatus is a snapshot of the job queue for the admin API.
+type QueueStatus struct {
+	Paused   bool         `json:"paused"`
+	Pending  int64        `json:"pending"`
+	Capacity int          `json:"capacity"`
+	Users    []UserLoad   `json:"users"`
+	Running  []RunningJob `json:"running"`
+	Workers  WorkerUsage  `json:"workers"`
+}
+
+// UserLoad is how many jobs one user has waiting and in progress.
+type UserLoad struct {
+	UserID  uint64 `json:"user_id"`
+	Queued  int64  `json:"queued"`
+	Running int64  `json:"running"`
+}
+
+// RunningJob is a leased job with its crawl's elapsed time and progress.
+// Progress is the percentage of the crawl's last event as the broker
+// saw it, from any process with DBBroker; -1 before the first event.
+type RunningJob struct {
+	JobID       uint64    `json:"job_id"`
+	URLID       uint64    `json:"url_id"`
+	UserID      uint64    `json:"user_id"`
+	OriginalURL string    `json:"original_url"`
+	Priority    Priority  `json:"priority"`
+	WorkerID    string    `json:"worker_id"`
+	ClaimedAt   time.Time `json:"claimed_at"`
+	ElapsedMs   int64     `json:"elapsed_ms"`
+	Progress    int       `json:"progress"`
+}
+
+// WorkerUsage counts the workers of this process's pool, stopping ones
+// included.
+type WorkerUsage struct {
+	Total       int64   `json:"total"`
+	Busy        int64   `json:"busy"`
+	Utilisation float64 `json:"utilisation"` // busy / total, 0 when there are no workers
+}
+
+// Status reports the queue as seen from this process.
+func Status() (QueueStatus, error) {
+	s := QueueStatus{Capacity: cfg.QueueCapacity, Users: []UserLoad{}, Running: []RunningJob{}}
+
+	var err error
+	if s.Paused, err = QueuePaused(); err != nil {
+		return s, err
+	}
+	if s.Pending, err = QueueDepth(); err != nil {
+		return s, err
+	}
+
+	if err := database.DB.Model(&models.Job{}).
+		Select("user_id, "+
+			"SUM(CASE WHEN state = 'queued' THEN 1 ELSE 0 END) AS queued, "+
+			"SUM(CASE WHEN state = 'leased' THEN 1 ELSE 0 END) AS running").
+		Where("state IN ?", []string{"queued", "leased"}).
+		Group("user_id").Order("user_id").
+		Scan(&s.Users).Error; err != nil {
+		return s, err
+	}
+
+	if err := database.DB.Model(&models.Job{}).
+		Select("jobs.id AS job_id, jobs.url_id, jobs.user_id, urls.original_url, "+
+			"jobs.priority, jobs.worker_id, jobs.claimed_at").
+		Joins("JOIN urls ON urls.id = jobs.url_id").
+		Where("jobs.state = ?", "leased").
+		Order("jobs.claimed_at").
+		Scan(&s.Running).Error; err != nil {
+		return s, err
+	}
+	now := time.Now()
+	for i := range s.Running {
+		r := &s.Running[i]
+		r.ElapsedMs = now.Sub(r.ClaimedAt).Milliseconds()
+		r.Progress = -1
+		if p, ok := Current(r.URLID); ok {
+			r.Progress = p.Pct
+		}
+	}
+
+	if Workers != nil {
+		for _, w := range Workers.States() {
+			s.Workers.Total++
+			if w.State == "crawling" {
+				s.Workers.Busy++
+			}
+		}
+	}
+	if s.Workers.Total > 0 {
+		s.Workers.Utilisation = float64(s.Workers.Busy) / float64(s.Workers.Total)
+	}
+	return s, nil
+}
+
+// QueuePaused reports whether workers are holding off claiming jobs.
+func QueuePaused() (bool, error) {
+	var st models.Setting
+	res := database.DB.Where(&models.Setting{Key: settingQueuePaused}).Limit(1).Find(&st)
+	return st.Value == "true", res.Error
+}
+
+// PauseQueue stops every worker process from claiming new jobs; crawls
+// already running finish normally and Enqueue keeps accepting jobs.
+func PauseQueue() error { return setQueuePaused(true) }
+
+// ResumeQueue lets workers claim jobs again.
+func ResumeQueue() error {
+	if err := setQueuePaused(false); err != nil {
+		return err
+	}
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func setQueuePaused(paused bool) error {
+	v := "false"
+	if paused {
+		v = "true"
+	}
+	return database.DB.Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&models.Setting{Key: settingQueuePaused, Value: v}).Error
+}
+
+// DrainUser drops every queued (not yet running) job of userID and
+// returns how many were dropped. The URLs fall back to the status of
+// their latest run, or "error" if they were never crawled.
+func DrainUser(userID uint64) (int64, error) {
+	var urlIDs []uint64
+	var drained int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// locked rows are skipped by claim, so none starts meanwhile
+		if err := tx.Model(&models.Job{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND state = ?", userID, "queued").
+			Pluck("url_id", &urlIDs).Error; err != nil || len(urlIDs) == 0 {
+			return err
+		}
+
+		res := tx.Model(&models.Job{}).
+			Where("user_id = ? AND state = ? AND url_id IN ?", userID, "queued", urlIDs).
+			Update("state", "drained")
+		if res.Error != nil {
+			return res.Error
+		}
+		drained = res.RowsAffected
+
+		return tx.Model(&models.URL{}).
+			Where("id IN ? AND crawl_status = ?", urlIDs, "queued").
+			Update("crawl_status", tx.Raw(
+				"COALESCE((SELECT status FROM crawl_runs WHERE crawl_runs.id = urls.latest_run_id AND status IN ?), ?)",
+				[]string{"done", "error", "cancelled"}, "error")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	announce(urlIDs...)
+	return drained, nil
+}
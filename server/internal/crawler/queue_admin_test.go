@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestPauseQueueStopsClaims(t *testing.T) {
+	test.InitInMemoryDB()
+	Enqueue(PriorityBulk, seedURLs(1)...)
+
+	if err := PauseQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if j, err := claim("w"); j != nil || err != nil {
+		t.Fatalf("claimed %+v, %v while paused", j, err)
+	}
+	s, _ := Status()
+	if !s.Paused || s.Pending != 1 {
+		t.Fatalf("status = %+v; want paused with 1 pending", s)
+	}
+
+	ResumeQueue()
+	if j, _ := claim("w"); j == nil {
+		t.Fatal("nothing claimed after resume")
+	}
+	s, _ = Status()
+	if s.Paused || s.Pending != 0 || len(s.Running) != 1 || s.Running[0].Progress != -1 {
+		t.Fatalf("status = %+v; want one running job", s)
+	}
+}
+
+func TestDrainUser(t *testing.T) {
+	test.InitInMemoryDB()
+	mine := seedURLs(1, 1, 1)
+	other := seedURLs(2)
+
+	for i, status := range []string{"done", "cancelled"} {
+		run := models.CrawlRun{URLID: mine[i], Status: status}
+		database.DB.Create(&run)
+		database.DB.Model(&models.URL{}).Where("id = ?", mine[i]).Update("latest_run_id", run.ID)
+	}
+	Enqueue(PriorityBulk, append(mine, other...)...)
+
+	n, err := DrainUser(1)
+	if err != nil || n != 3 {
+		t.Fatalf("DrainUser = %d, %v; want 3", n, err)
+	}
+
+	s, _ := Status()
+	if s.Pending != 1 || len(s.Users) != 1 || s.Users[0].UserID != 2 {
+		t.Fatalf("status after drain = %+v", s)
+	}
+	var urls []models.URL
+	database.DB.Order("id").Find(&urls)
+	var got []string
+	for _, u := range urls {
+		got = append(got, u.CrawlStatus)
+	}
+	if want := []string{"done", "cancelled", "error", "queued"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("url statuses = %v; want %v", got, want)
+	}
+}
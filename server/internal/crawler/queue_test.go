@@ -0,0 +1,216 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+// seedURLs stores one URL per owner and returns their ids.
+func seedURLs(owners ...uint64) []uint64 {
+	ids := make([]uint64, len(owners))
+	for i, uid := range owners {
+		u := models.URL{OriginalURL: fmt.Sprintf("https://%d.test/%d", uid, i), UserID: uid}
+		database.DB.Create(&u)
+		ids[i] = u.ID
+	}
+	return ids
+}
+
+func TestQueueClaimAndComplete(t *testing.T) {
+	test.InitInMemoryDB()
+	seedURLs(1, 1)
+
+	tickets, err := Enqueue(PriorityBulk, 1, 2, 1, 99) // duplicate and unknown ids are skipped
+	if err != nil || len(tickets) != 2 || tickets[1].Position != 2 {
+		t.Fatalf("Enqueue = %+v, %v", tickets, err)
+	}
+	var n int64
+	database.DB.Model(&models.Job{}).Count(&n)
+	if n != 2 {
+		t.Fatalf("%d jobs; want 2", n)
+	}
+
+	a, err := claim("w1")
+	if err != nil || a == nil || a.URLID != 1 || a.Attempts != 1 {
+		t.Fatalf("first claim = %+v, %v", a, err)
+	}
+	b, _ := claim("w2")
+	if b == nil || b.URLID != 2 {
+		t.Fatalf("second claim = %+v", b)
+	}
+	if c, _ := claim("w3"); c != nil {
+		t.Fatalf("claimed leased job %+v", c)
+	}
+
+	if renew(a, "w2") {
+		t.Fatal("renewed a lease held by another worker")
+	}
+	complete(a, "w1")
+	database.DB.First(a, a.ID)
+	if a.State != "done" {
+		t.Fatalf("state = %s; want done", a.State)
+	}
+
+	// a finished job no longer blocks a new one for the same URL
+	if _, err := Enqueue(PriorityBulk, 1); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := claim("w3"); c == nil || c.URLID != 1 || c.ID == a.ID {
+		t.Fatalf("re-enqueued claim = %+v", c)
+	}
+}
+
+func TestQueueRecoversExpiredLeases(t *testing.T) {
+	test.InitInMemoryDB()
+
+	urls := []models.URL{
+		{OriginalURL: "https://a.test", UserID: 1, CrawlStatus: "running"},
+		{OriginalURL: "https://b.test", UserID: 1, CrawlStatus: "running"},
+	}
+	database.DB.Create(&urls)
+	expired := time.Now().Add(-time.Minute)
+	w := "dead"
+	jobs := []models.Job{
+		{URLID: urls[0].ID, State: "leased", Attempts: 1, WorkerID: &w, LeaseUntil: &expired},
+		{URLID: urls[1].ID, State: "leased", Attempts: maxAttempts, WorkerID: &w, LeaseUntil: &expired},
+	}
+	database.DB.Create(&jobs)
+	database.DB.Create(&models.CrawlRun{URLID: urls[0].ID, Status: "running", StartedAt: expired})
+
+	if err := Recover(); err != nil {
+		t.Fatal(err)
+	}
+
+	database.DB.Find(&jobs)
+	if jobs[0].State != "queued" || jobs[0].WorkerID != nil || jobs[1].State != "failed" {
+		t.Fatalf("states = %s, %s; want queued, failed", jobs[0].State, jobs[1].State)
+	}
+	database.DB.Find(&urls)
+	if urls[0].CrawlStatus != "queued" || urls[1].CrawlStatus != "error" {
+		t.Fatalf("url statuses = %s, %s", urls[0].CrawlStatus, urls[1].CrawlStatus)
+	}
+	var run models.CrawlRun
+	database.DB.First(&run)
+	if run.Status != "error" || run.FinishedAt == nil {
+		t.Fatalf("interrupted run = %+v", run)
+	}
+
+	if j, _ := claim("w1"); j == nil || j.ID != jobs[0].ID || j.Attempts != 2 {
+		t.Fatalf("claim after recovery = %+v", j)
+	}
+}
+
+func TestClaimClosesDeadWorkersRun(t *testing.T) {
+	test.InitInMemoryDB()
+
+	// the worker died and the process came back before Recover could see
+	// an expired lease; a live worker claims the job once it expires
+	u := models.URL{OriginalURL: "https://a.test", UserID: 1, CrawlStatus: "running"}
+	database.DB.Create(&u)
+	expired := time.Now().Add(-time.Second)
+	w := "dead"
+	database.DB.Create(&models.Job{URLID: u.ID, State: "leased", Attempts: 1, WorkerID: &w, LeaseUntil: &expired})
+	orphan := models.CrawlRun{URLID: u.ID, Status: "running", StartedAt: expired}
+	database.DB.Create(&orphan)
+
+	if j, _ := claim("w1"); j == nil || j.Attempts != 2 {
+		t.Fatalf("takeover claim = %+v", j)
+	}
+	database.DB.First(&orphan, orphan.ID)
+	if orphan.Status != "error" || orphan.FinishedAt == nil {
+		t.Fatalf("dead worker's run = %+v; want closed as error", orphan)
+	}
+}
+
+func TestEnqueueRejectsWhenFull(t *testing.T) {
+	test.InitInMemoryDB()
+	defer Init(Config{})
+	Init(Config{QueueCapacity: 2})
+	ids := seedURLs(1, 1, 1)
+
+	if _, err := Enqueue(PriorityBulk, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Enqueue(PriorityBulk, ids[1], ids[2]); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("err = %v; want ErrQueueFull", err)
+	}
+	if n, _ := QueueDepth(); n != 1 { // rejected batch leaves nothing behind
+		t.Fatalf("depth = %d; want 1", n)
+	}
+
+	// re-queueing a URL that is already waiting needs no room, and can
+	// raise its priority
+	tickets, err := Enqueue(PriorityInteractive, ids[0])
+	if err != nil || len(tickets) != 1 || tickets[0].Position != 1 {
+		t.Fatalf("Enqueue = %+v, %v", tickets, err)
+	}
+	var j models.Job
+	database.DB.First(&j, tickets[0].JobID)
+	if Priority(j.Priority) != PriorityInteractive {
+		t.Fatalf("priority = %v; want interactive", Priority(j.Priority))
+	}
+}
+
+func TestConcurrentEnqueuesRespectCapacity(t *testing.T) {
+	test.InitInMemoryDB()
+	defer Init(Config{})
+	Init(Config{QueueCapacity: 3})
+	ids := seedURLs(1, 1, 1, 1, 1, 1, 1, 1)
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Enqueue(PriorityBulk, id); err != nil && !errors.Is(err, ErrQueueFull) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n, _ := QueueDepth(); n != 3 {
+		t.Fatalf("depth = %d; want 3", n)
+	}
+	var st models.Setting
+	if database.DB.First(&st, "`key` = ?", settingQueueLock).Error != nil {
+		t.Fatal("queue lock row missing")
+	}
+}
+
+func TestClaimOrdersByPriorityThenUser(t *testing.T) {
+	test.InitInMemoryDB()
+	heavy := seedURLs(1, 1, 1, 1)
+	light := seedURLs(2, 3)
+
+	Enqueue(PriorityBulk, heavy...)
+	Enqueue(PriorityScheduled, light[1])
+	Enqueue(PriorityBulk, light[0])
+	Enqueue(PriorityInteractive, heavy[3]) // bumps an already queued job
+
+	var got []uint64
+	for {
+		j, err := claim("w")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j == nil {
+			break
+		}
+		got = append(got, j.URLID)
+	}
+
+	// interactive first; then bulk alternating between users 1 and 2
+	// (user 2 never served yet); scheduled last
+	want := []uint64{heavy[3], light[0], heavy[0], heavy[1], heavy[2], light[1]}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("claim order %v; want %v", got, want)
+	}
+}
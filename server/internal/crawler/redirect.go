@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// maxRedirects is where the client gives up following a chain.
+const maxRedirects = 10
+
+var errRedirectLoop = fmt.Errorf("redirect loop: %w", errTooManyRedirects)
+
+// redirectTrace collects the hops of one request. It travels in the
+// request context so the shared client's CheckRedirect can fill it in.
+type redirectTrace struct {
+	hops []models.Redirect
+	loop bool
+}
+
+type traceKey struct{}
+
+func withTrace(ctx context.Context) (context.Context, *redirectTrace) {
+	t := &redirectTrace{}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// checkRedirect records the response that caused each redirect and stops
+// on loops and overly long chains.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	t, _ := req.Context().Value(traceKey{}).(*redirectTrace)
+	if t != nil && req.Response != nil {
+		t.add(req.Response)
+	}
+	for _, v := range via {
+		if v.URL.String() == req.URL.String() {
+			if t != nil {
+				t.loop = true
+			}
+			return errRedirectLoop
+		}
+	}
+	if len(via) >= maxRedirects {
+		return errTooManyRedirects
+	}
+	return nil
+}
+
+func (t *redirectTrace) add(res *http.Response) {
+	t.hops = append(t.hops, models.Redirect{
+		Hop:      len(t.hops) + 1,
+		URL:      res.Request.URL.String(),
+		Status:   res.StatusCode,
+		Location: res.Header.Get("Location"),
+	})
+}
+
+// finish appends the final response of a redirected request so the chain
+// reads e.g. 301 → 302 → 200.
+func (t *redirectTrace) finish(res *http.Response) {
+	if len(t.hops) > 0 {
+		t.add(res)
+	}
+}
+
+// redirects is the number of redirect responses in the chain.
+func (t *redirectTrace) redirects() int {
+	n := 0
+	for _, h := range t.hops {
+		if h.Location != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// long reports chains with more redirects than cfg.LongRedirectChain.
+func (t *redirectTrace) long() bool {
+	return t.redirects() > cfg.LongRedirectChain
+}
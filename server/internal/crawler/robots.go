@@ -0,0 +1,308 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*──────────────────────── rules ─────────────────────────*/
+
+// robotsRules is the group of a robots.txt that applies to our user-agent.
+type robotsRules struct {
+	rules []robotsRule
+	delay time.Duration // Crawl-delay, 0 if absent
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string // original path pattern, its length decides precedence
+	re      *regexp.Regexp
+}
+
+var (
+	allowAll    = &robotsRules{}
+	disallowAll = &robotsRules{rules: []robotsRule{{pattern: "/", re: compilePattern("/")}}}
+)
+
+// parseRobots extracts the rules for agent from a robots.txt body.
+// Groups naming agent's product token (case-insensitive) are merged;
+// "*" groups are the fallback.
+func parseRobots(body, agent string) *robotsRules {
+	token := strings.ToLower(agent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	type group struct {
+		agents []string
+		rules  []robotsRule
+		delay  time.Duration
+	}
+	var groups []*group
+	var cur *group
+	inRules := false
+
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			if cur == nil || inRules {
+				cur = &group{}
+				groups = append(groups, cur)
+				inRules = false
+			}
+			cur.agents = append(cur.agents, strings.ToLower(val))
+		case "allow", "disallow":
+			if cur == nil {
+				continue
+			}
+			inRules = true
+			if val == "" { // empty Disallow means "allow everything"
+				continue
+			}
+			cur.rules = append(cur.rules, robotsRule{
+				allow:   key == "allow",
+				pattern: val,
+				re:      compilePattern(val),
+			})
+		case "crawl-delay":
+			if cur == nil {
+				continue
+			}
+			inRules = true
+			if secs, err := strconv.ParseFloat(val, 64); err == nil && secs > 0 {
+				cur.delay = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	// our own token beats the wildcard group
+	best := ""
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == token || (a == "*" && best == "") {
+				best = a
+			}
+		}
+	}
+	if best == "" {
+		return allowAll
+	}
+
+	out := &robotsRules{}
+	for _, g := range groups {
+		for _, a := range g.agents {
+			if a == best {
+				out.rules = append(out.rules, g.rules...)
+				out.delay = max(out.delay, g.delay)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// compilePattern turns a robots.txt path pattern into an anchored regexp:
+// "*" matches any run of characters and a trailing "$" anchors the end.
+func compilePattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+
+	parts := strings.Split(p, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed applies longest-match precedence; Allow wins ties.
+func (r *robotsRules) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allow, matched := true, -1
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > matched || (n == matched && rule.allow) {
+			allow, matched = rule.allow, n
+		}
+	}
+	return allow
+}
+
+/*──────────────────────── cache ─────────────────────────*/
+
+const (
+	robotsTTL      = 24 * time.Hour
+	robotsErrorTTL = 5 * time.Minute // server and network errors are retried sooner
+)
+
+// robotsCache fetches robots.txt once per scheme+host and remembers the
+// rules for robotsTTL. Expired entries are swept at most once per
+// robotsErrorTTL so hosts seen once do not pile up.
+type robotsCache struct {
+	mu    sync.Mutex
+	hosts map[string]*robotsEntry
+	swept time.Time
+}
+
+type robotsEntry struct {
+	ready     chan struct{} // closed once rules is set
+	rules     *robotsRules
+	expires   time.Time
+	abandoned bool // the fetching caller gave up; waiters fetch again
+}
+
+var robots = &robotsCache{hosts: map[string]*robotsEntry{}}
+
+// Allowed reports whether our user-agent may request u. URLs that are not
+// http(s) are not governed by robots.txt and are always allowed.
+func (c *robotsCache) Allowed(ctx context.Context, u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return true
+	}
+	path := parsed.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if parsed.RawQuery != "" {
+		path += "?" + parsed.RawQuery
+	}
+	return c.rules(ctx, parsed).allowed(path)
+}
+
+// Delay returns the Crawl-delay of u's host, 0 if none.
+func (c *robotsCache) Delay(ctx context.Context, u string) time.Duration {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return 0
+	}
+	return c.rules(ctx, parsed).delay
+}
+
+// rules returns the cached rules for the URL's host, fetching them if
+// missing or stale. Concurrent callers share a single fetch.
+func (c *robotsCache) rules(ctx context.Context, u *url.URL) *robotsRules {
+	key := u.Scheme + "://" + u.Host
+	for {
+		c.mu.Lock()
+		e, ok := c.hosts[key]
+		if ok && e.done() && time.Now().After(e.expires) {
+			ok = false
+		}
+		if !ok {
+			c.sweep()
+			e = &robotsEntry{ready: make(chan struct{})}
+			c.hosts[key] = e
+			c.mu.Unlock()
+			return c.fetch(ctx, key, e)
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-e.ready:
+			if !e.abandoned {
+				return e.rules
+			}
+		case <-ctx.Done():
+			return allowAll // the caller's request fails on ctx anyway
+		}
+	}
+}
+
+// fetch fills e with the rules of origin. A fetch cut short by ctx is
+// not cached.
+func (c *robotsCache) fetch(ctx context.Context, origin string, e *robotsEntry) *robotsRules {
+	rules, ttl := fetchRobots(ctx, origin)
+	if ctx.Err() != nil {
+		c.mu.Lock()
+		if c.hosts[origin] == e {
+			delete(c.hosts, origin)
+		}
+		c.mu.Unlock()
+		e.abandoned = true
+		close(e.ready)
+		return allowAll
+	}
+	e.rules, e.expires = rules, time.Now().Add(ttl)
+	close(e.ready)
+	return rules
+}
+
+// sweep drops expired entries; c.mu must be held.
+func (c *robotsCache) sweep() {
+	now := time.Now()
+	if now.Sub(c.swept) < robotsErrorTTL {
+		return
+	}
+	c.swept = now
+	for k, e := range c.hosts {
+		if e.done() && now.After(e.expires) {
+			delete(c.hosts, k)
+		}
+	}
+}
+
+func (e *robotsEntry) done() bool {
+	select {
+	case <-e.ready:
+		return true
+	default: // fetch in flight
+		return false
+	}
+}
+
+// fetchRobots downloads and parses origin/robots.txt and says how long
+// the result may be cached. Following RFC 9309 a missing file (4xx)
+// allows everything and a server error (5xx) disallows everything, for
+// robotsErrorTTL only; network errors allow everything so the link check
+// itself reports the unreachable host.
+func fetchRobots(ctx context.Context, origin string) (*robotsRules, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := newRequest(ctx, http.MethodGet, origin+"/robots.txt")
+	if err != nil {
+		return allowAll, robotsTTL
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return allowAll, robotsErrorTTL
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 500:
+		return disallowAll, robotsErrorTTL
+	case res.StatusCode >= 400:
+		return allowAll, robotsTTL
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 500<<10)) // 500 KiB, as per RFC
+	return parseRobots(string(body), cfg.UserAgent), robotsTTL
+}
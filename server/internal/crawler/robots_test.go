@@ -0,0 +1,96 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	body := `
+# generic rules
+User-agent: *
+Disallow: /private
+Allow: /private/open
+
+User-agent: OtherBot
+User-agent: webcrawlerbot
+Disallow: /*.pdf$
+Disallow: /tmp/
+Allow: /tmp/keep
+Crawl-delay: 1.5
+`
+	ours := parseRobots(body, "WebCrawlerBot/1.0")
+	cases := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/private", true}, // our own group replaces the * group
+		{"/doc.pdf", false},
+		{"/doc.pdf?x=1", true}, // $ anchors the end
+		{"/a/b/doc.pdf", false},
+		{"/tmp/x", false},
+		{"/tmp/keep/x", true}, // longer Allow beats shorter Disallow
+		{"/robots.txt", true},
+	}
+	for _, c := range cases {
+		if got := ours.allowed(c.path); got != c.want {
+			t.Errorf("allowed(%q) = %v; want %v", c.path, got, c.want)
+		}
+	}
+	if ours.delay != 1500*time.Millisecond {
+		t.Errorf("delay = %v; want 1.5s", ours.delay)
+	}
+
+	other := parseRobots(body, "SomeoneElse")
+	if other.allowed("/private/x") || !other.allowed("/private/open/x") {
+		t.Errorf("wildcard group not applied")
+	}
+	if parseRobots("", "x").allowed("/anything") != true {
+		t.Errorf("empty robots.txt must allow everything")
+	}
+}
+
+func TestRobotsCacheExpiry(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	c := &robotsCache{hosts: map[string]*robotsEntry{}}
+	stale := &robotsEntry{ready: make(chan struct{}), expires: time.Now().Add(-time.Second)}
+	close(stale.ready)
+	c.hosts["http://gone.test"] = stale
+
+	// a server error disallows everything, but only for a short while
+	if c.Allowed(context.Background(), srv.URL+"/page") {
+		t.Fatal("allowed during a robots.txt server error")
+	}
+	e := c.hosts[srv.URL]
+	if left := time.Until(e.expires); left > robotsErrorTTL {
+		t.Fatalf("server error cached for %v; want at most %v", left, robotsErrorTTL)
+	}
+	if _, ok := c.hosts["http://gone.test"]; ok {
+		t.Fatal("expired entry not swept")
+	}
+
+	status.Store(http.StatusNotFound)
+	e.expires = time.Now().Add(-time.Second)
+	if !c.Allowed(context.Background(), srv.URL+"/page") {
+		t.Fatal("robots.txt not fetched again after the error expired")
+	}
+
+	// a fetch the caller gave up on is not cached
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Allowed(ctx, "http://127.0.0.1:1/page")
+	if _, ok := c.hosts["http://127.0.0.1:1"]; ok {
+		t.Fatal("cancelled fetch cached")
+	}
+}
@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+)
+
+// NextRun returns the first time after from at which spec fires. spec is
+// a standard 5-field cron expression ("0 7 * * *"), a descriptor such as
+// "@daily", or a fixed interval ("@every 6h").
+func NextRun(spec string, from time.Time) (time.Time, error) {
+	s, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Next(from), nil
+}
+
+/*───────────────── Scheduler loop ──────────────*/
+
+// Scheduler enqueues URLs whose schedule is due every tick until ctx is
+// done. URLs already queued or running are skipped for this tick.
+func Scheduler(ctx context.Context, tick time.Duration) {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			enqueueDue(now)
+		}
+	}
+}
+
+func enqueueDue(now time.Time) {
+	var due []models.URL
+	if err := database.DB.
+		Where("schedule IS NOT NULL AND schedule_paused = ?", false).
+		Where("next_run_at <= ?", now).
+		Where("crawl_status NOT IN ?", []string{"queued", "running"}).
+		Find(&due).Error; err != nil {
+		log.Println("scheduler:", err)
+		return
+	}
+
+	for _, u := range due {
+		next, err := NextRun(*u.Schedule, now)
+		if err != nil { // stored specs are validated; pause broken ones
+			database.DB.Model(&u).Update("schedule_paused", true)
+			continue
+		}
+		if _, err := Enqueue(PriorityScheduled, u.ID); err != nil { // full: retry next tick
+			log.Println("scheduler:", err)
+			continue
+		}
+		database.DB.Model(&u).Update("next_run_at", next)
+	}
+}
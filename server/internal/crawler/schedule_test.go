@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestNextRun(t *testing.T) {
+	from := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"0 7 * * *":  time.Date(2024, 5, 2, 7, 0, 0, 0, time.UTC),
+		"@hourly":    time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		"@every 90m": from.Add(90 * time.Minute),
+	}
+	for spec, want := range cases {
+		got, err := NextRun(spec, from)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("NextRun(%q) = %v, %v; want %v", spec, got, err, want)
+		}
+	}
+	if _, err := NextRun("every tuesday", from); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+}
+
+func TestEnqueueDue(t *testing.T) {
+	test.InitInMemoryDB()
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	daily := "@daily"
+	urls := []models.URL{
+		{OriginalURL: "https://due.test", UserID: 1, CrawlStatus: "done", Schedule: &daily, NextRunAt: &past},
+		{OriginalURL: "https://later.test", UserID: 1, CrawlStatus: "done", Schedule: &daily, NextRunAt: &future},
+		{OriginalURL: "https://paused.test", UserID: 1, CrawlStatus: "done", Schedule: &daily, NextRunAt: &past, SchedulePaused: true},
+		{OriginalURL: "https://busy.test", UserID: 1, CrawlStatus: "running", Schedule: &daily, NextRunAt: &past},
+		{OriginalURL: "https://manual.test", UserID: 1, CrawlStatus: "done"},
+	}
+	database.DB.Create(&urls)
+
+	enqueueDue(now)
+
+	var jobs []models.Job
+	database.DB.Find(&jobs)
+	if len(jobs) != 1 || jobs[0].URLID != urls[0].ID {
+		t.Fatalf("enqueued %+v; want one job for URL %d", jobs, urls[0].ID)
+	}
+
+	var u models.URL
+	database.DB.First(&u, urls[0].ID)
+	if u.CrawlStatus != "queued" || u.NextRunAt == nil || !u.NextRunAt.After(now) {
+		t.Fatalf("due URL not rescheduled: status %s next %v", u.CrawlStatus, u.NextRunAt)
+	}
+}
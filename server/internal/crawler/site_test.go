@@ -0,0 +1,222 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestCrawlSiteHonoursDepth(t *testing.T) {
+	test.InitInMemoryDB()
+
+	pages := map[string]string{
+		"/":  `<title>root</title><h1>x</h1><a href="/a">a</a><a href="/b#top">b</a><a href="/missing">m</a><a href="/secret">s</a>`,
+		"/a": `<title>a</title><a href="/">home</a>`,
+		"/b": `<title>b</title><a href="/c">c</a>`,
+		"/c": `<title>c</title>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /secret\n")
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<!DOCTYPE html>"+body)
+	}))
+	defer srv.Close()
+
+	rec := models.URL{OriginalURL: srv.URL + "/", UserID: 1, MaxDepth: 1, MaxPages: 10}
+	database.DB.Create(&rec)
+
+	crawl(rec.ID)
+
+	database.DB.First(&rec, rec.ID)
+	if rec.CrawlStatus != "done" || rec.Title == nil || *rec.Title != "root" || rec.H1 != 1 {
+		t.Fatalf("unexpected root result: %+v", rec)
+	}
+	if rec.BrokenLinks != 1 || rec.BlockedByRobots != 1 {
+		t.Fatalf("broken = %d, blocked = %d; want 1, 1", rec.BrokenLinks, rec.BlockedByRobots)
+	}
+
+	var got []models.Page
+	database.DB.Order("id").Find(&got)
+	if len(got) != 3 { // /, /a, /b — /c is at depth 2
+		t.Fatalf("crawled %d pages; want 3", len(got))
+	}
+	for _, p := range got[1:] {
+		if p.Depth != 1 || p.ParentID == nil || *p.ParentID != got[0].ID {
+			t.Fatalf("page %s: depth %d parent %v", p.URL, p.Depth, p.ParentID)
+		}
+	}
+}
+
+func TestCrawlCutsOffLargePages(t *testing.T) {
+	test.InitInMemoryDB()
+	defer Init(Config{})
+	Init(Config{MaxPageBytes: 1 << 10})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<title>big</title><a href="/early">e</a>`+strings.Repeat(" ", 4<<10)+`<a href="/late">l</a>`)
+	}))
+	defer srv.Close()
+
+	rec := models.URL{OriginalURL: srv.URL + "/", UserID: 1}
+	database.DB.Create(&rec)
+	crawl(rec.ID)
+
+	database.DB.First(&rec, rec.ID)
+	if rec.CrawlStatus != "done" || rec.Title == nil || *rec.Title != "big" || rec.InternalLinks != 1 {
+		t.Fatalf("crawl of a cut-off page = %+v; want done with the first link only", rec)
+	}
+}
+
+func TestCrawlRecordsRedirects(t *testing.T) {
+	test.InitInMemoryDB()
+
+	hops := map[string]string{
+		"/start":  "/mid",
+		"/mid":    "/",
+		"/moved":  "/",
+		"/loop-a": "/loop-b",
+		"/loop-b": "/loop-a",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if to, ok := hops[r.URL.Path]; ok {
+			http.Redirect(w, r, to, http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="/moved">m</a><a href="/loop-a">l</a>`)
+	}))
+	defer srv.Close()
+
+	rec := models.URL{OriginalURL: srv.URL + "/start", UserID: 1, MaxPages: 1}
+	database.DB.Create(&rec)
+
+	crawl(rec.ID)
+
+	database.DB.Preload("Redirects", "link_id IS NULL").First(&rec, rec.ID)
+	if rec.FinalURL == nil || *rec.FinalURL != srv.URL+"/" || len(rec.Redirects) != 3 {
+		t.Fatalf("root chain: final %v, %d hops", rec.FinalURL, len(rec.Redirects))
+	}
+	if rec.RedirectedLinks != 2 {
+		t.Fatalf("redirected_links = %d; want 2", rec.RedirectedLinks)
+	}
+
+	var loop models.Link
+	database.DB.Preload("Redirects").Where("href = ?", srv.URL+"/loop-a").First(&loop)
+	if !loop.RedirectLoop || loop.ErrorKind != ErrKindTooManyRedirects || len(loop.Redirects) != 2 {
+		t.Fatalf("loop link: %+v", loop)
+	}
+}
+
+func TestRecrawlKeepsHistory(t *testing.T) {
+	test.InitInMemoryDB()
+
+	title := "first"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<title>%s</title><a href="/x">x</a>`, title)
+	}))
+	defer srv.Close()
+
+	rec := models.URL{OriginalURL: srv.URL, UserID: 1, MaxPages: 1}
+	database.DB.Create(&rec)
+
+	crawl(rec.ID)
+	title = "second"
+	crawl(rec.ID)
+
+	var runs []models.CrawlRun
+	database.DB.Where("url_id = ?", rec.ID).Order("id").Find(&runs)
+	if len(runs) != 2 || *runs[0].Title != "first" || *runs[1].Title != "second" {
+		t.Fatalf("runs = %+v", runs)
+	}
+
+	database.DB.First(&rec, rec.ID)
+	if rec.LatestRunID == nil || *rec.LatestRunID != runs[1].ID || *rec.Title != "second" {
+		t.Fatalf("url does not point at the latest run: %+v", rec)
+	}
+
+	for _, run := range runs {
+		var n int64
+		database.DB.Model(&models.Link{}).Where("run_id = ?", run.ID).Count(&n)
+		if n != 1 {
+			t.Fatalf("run %d has %d links; want 1", run.ID, n)
+		}
+	}
+}
+
+func TestCrawlPublishesTypedEvents(t *testing.T) {
+	test.InitInMemoryDB()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<title>t</title><a href="/missing">m</a><a href="https://example.invalid/">x</a>`)
+	}))
+	defer srv.Close()
+
+	rec := models.URL{OriginalURL: srv.URL + "/", UserID: 1, MaxPages: 1}
+	database.DB.Create(&rec)
+
+	ch, unsubscribe := Subscribe(rec.ID)
+	defer unsubscribe()
+	crawl(rec.ID)
+
+	var events []Event
+	for len(events) == 0 || events[len(events)-1].Type != EventDone {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			t.Fatalf("stream ended without done: %+v", events)
+		}
+	}
+
+	var stages []Stage
+	links := 0
+	var counts *Counts
+	for i, e := range events {
+		if e.ID != uint64(i+1) || e.URLID != rec.ID {
+			t.Fatalf("event %d has id %d, url %d", i, e.ID, e.URLID)
+		}
+		switch e.Type {
+		case EventStage:
+			stages = append(stages, e.Stage)
+		case EventLink:
+			links++
+		case EventCounts:
+			counts = e.Counts
+		}
+	}
+	if fmt.Sprint(stages) != "[fetching parsing checking_links]" {
+		t.Fatalf("stages = %v", stages)
+	}
+	if links != 2 {
+		t.Fatalf("got %d link events; want 2", links)
+	}
+	if counts == nil || *counts != (Counts{Pages: 1, Internal: 1, External: 1, Broken: 1}) {
+		t.Fatalf("counts = %+v", counts)
+	}
+	done := events[len(events)-1]
+	if done.Pct != 100 || done.Summary.Status != "done" || done.Summary.Pages != 1 ||
+		*done.Summary.Title != "t" {
+		t.Fatalf("done = %+v, summary %+v", done, done.Summary)
+	}
+}
@@ -2,7 +2,10 @@ package crawler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -10,46 +13,72 @@ import (
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/zeewaqar/web-crawler/server/internal/alert"
 	"github.com/zeewaqar/web-crawler/server/internal/database"
 	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+	"github.com/zeewaqar/web-crawler/server/internal/webhook"
+	"gorm.io/gorm"
 )
 
 /*──────────────────────── globals ───────────────────────*/
 
-var client = &http.Client{Timeout: 10 * time.Second}
+var client = &http.Client{Timeout: 10 * time.Second, CheckRedirect: checkRedirect, Transport: netguard.Transport()}
+
+var errBlockedByRobots = errors.New("disallowed by robots.txt")
 
 var (
-	cancelMap   = map[uint64]context.CancelFunc{}
-	cancelMutex sync.Mutex
-	wg          sync.WaitGroup
+	errCancelled = errors.New("crawl cancelled")      // a user asked to stop
+	errShutdown  = errors.New("worker shutting down") // the pool hit its deadline
+	errLeaseLost = errors.New("lease lost")           // another worker has the job now
 )
 
-/*──────── queue helpers (called from handlers) ─────────*/
+var (
+	cancelMap   = map[uint64]context.CancelCauseFunc{}
+	cancelMutex sync.Mutex
+)
 
-func Cancel(id uint64) {
+// cancelLocal stops the crawl of URL id if it runs in this process.
+func cancelLocal(id uint64, cause error) {
 	cancelMutex.Lock()
 	if fn, ok := cancelMap[id]; ok {
-		fn()
+		fn(cause)
 	}
 	cancelMutex.Unlock()
 }
 
-func Wait() { wg.Wait() }
+/*───────────────── crawl one URL ───────────────*/
 
-/*───────────────── Worker loop ─────────────────*/
+// crawlJob is the per-crawl state shared by page fetches and link checks.
+type crawlJob struct {
+	rec    *models.URL
+	run    *models.CrawlRun
+	limits hostLimits
+	prog   *tracker
 
-func Worker(jobs <-chan uint64) {
-	for id := range jobs {
-		wg.Add(1)
-		crawl(id)
-		wg.Done()
-	}
+	mu       sync.Mutex
+	statuses map[string]*linkResult // href → check result, shared by all pages
 }
 
-/*───────────────── crawl one URL ───────────────*/
+// pageTask is one entry of the breadth-first crawl frontier.
+type pageTask struct {
+	url      string
+	depth    int
+	parentID *uint64
+}
+
+// crawl runs one crawl of URL id. interrupted reports that Shutdown or a
+// lost lease cut it short, so its job is not done.
+func crawl(id uint64) (interrupted bool) {
+	/* 1. fetch db record */
+	var rec models.URL
+	if err := database.DB.First(&rec, id).Error; err != nil {
+		return
+	}
+	maxPages := max(rec.MaxPages, 1)
 
-func crawl(id uint64) {
-	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	base, cancel := context.WithCancelCause(context.Background())
+	ctx, stop := context.WithTimeout(base, crawlTimeout(maxPages))
 
 	/* register for /stop */
 	cancelMutex.Lock()
@@ -57,128 +86,361 @@ func crawl(id uint64) {
 	cancelMutex.Unlock()
 
 	defer func() {
-		cancel() // safety
+		stop()
+		cancel(nil) // safety
 		cancelMutex.Lock()
 		delete(cancelMap, id) // cleanup map
 		cancelMutex.Unlock()
 	}()
 
-	/* 1. fetch db record */
-	var rec models.URL
-	if err := database.DB.First(&rec, id).Error; err != nil {
+	// a stop may have been requested between claim and registration
+	if cancelRequested(id) {
+		cancel(errCancelled)
+	}
+
+	/* 2. open a new run; earlier runs stay untouched */
+	run := models.CrawlRun{URLID: rec.ID, Status: "running", StartedAt: time.Now()}
+	if err := database.DB.Create(&run).Error; err != nil {
 		return
 	}
 
-	/* 2. mark running & reset stats */
+	/* mark running & reset the summary */
 	database.DB.Model(&rec).Updates(map[string]any{
 		"crawl_status":   "running",
+		"latest_run_id":  run.ID,
+		"last_run_at":    run.StartedAt,
 		"internal_links": 0, "external_links": 0, "broken_links": 0,
-		"h1": 0, "h2": 0, "h3": 0,
+		"blocked_by_robots": 0, "unchecked_links": 0, "redirected_links": 0,
+		"final_url": nil, "redirect_loop": false, "h1": 0, "h2": 0, "h3": 0,
 		"has_login": false,
 	})
-	Publish(id, 0)
+	announce(id)
+	job := &crawlJob{
+		rec:      &rec,
+		run:      &run,
+		limits:   limitsFor(rec.HostConcurrency, rec.HostIntervalMs),
+		prog:     &tracker{id: id, user: rec.UserID, run: run.ID},
+		statuses: map[string]*linkResult{},
+	}
+	job.prog.start()
+
+	// end closes the run. A crawl cut short by Shutdown or a lost lease
+	// is not finished: its job runs again from scratch, here or elsewhere.
+	end := func(s *Summary) {
+		if cause := context.Cause(ctx); errors.Is(cause, errShutdown) || errors.Is(cause, errLeaseLost) {
+			job.interrupt()
+			interrupted = true
+			return
+		}
+		job.finish(s)
+	}
 
-	/* 3. download page */
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, rec.OriginalURL, nil)
-	resp, err := client.Do(req)
-	if err != nil || resp.StatusCode >= 400 {
-		fail(rec.ID)
+	/* 3. breadth-first walk over internal pages */
+	frontier := []pageTask{{url: rec.OriginalURL}}
+	seen := map[string]bool{normalize(rec.OriginalURL): true}
+
+	var root *models.Page
+	internal, external, broken, blocked, unchecked, redirected := 0, 0, 0, 0, 0, 0
+
+	done := 0
+	for len(frontier) > 0 && done < maxPages && ctx.Err() == nil {
+		task := frontier[0]
+		frontier = frontier[1:]
+		planned := min(maxPages, done+len(frontier)+1)
+
+		page, next, err := job.crawlPage(ctx, task, func(frac float64) {
+			job.prog.page(done, planned, frac)
+		})
+		if err != nil {
+			job.prog.fail(task.url, err)
+			if root == nil {
+				end(&Summary{Status: failure(ctx)})
+				return
+			}
+			continue // unreachable sub-pages are already reported as links
+		}
+		if root == nil {
+			root = page
+		}
+		done++
+
+		internal += page.InternalLinks
+		external += page.ExternalLinks
+		broken += page.BrokenLinks
+		blocked += page.BlockedByRobots
+		unchecked += page.UncheckedLinks
+		redirected += page.RedirectedLinks
+		job.prog.counts(Counts{Pages: done, Internal: internal, External: external, Broken: broken})
+
+		if task.depth >= rec.MaxDepth {
+			continue
+		}
+		for _, u := range next {
+			if key := normalize(u); !seen[key] {
+				seen[key] = true
+				frontier = append(frontier, pageTask{url: key, depth: task.depth + 1, parentID: &page.ID})
+			}
+		}
+	}
+	if root == nil { // stopped before the root page finished
+		end(&Summary{Status: failure(ctx)})
 		return
 	}
-	defer resp.Body.Close()
 
-	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
-		fail(rec.ID)
-		return
+	/* 4. final update: root page metadata + totals over all pages */
+	status := "done"
+	if errors.Is(context.Cause(ctx), errCancelled) { // keep what was found so far
+		status = "cancelled"
 	}
+	end(&Summary{
+		Status:      status,
+		Pages:       done,
+		HTMLVersion: root.HTMLVersion,
+		Title:       root.Title,
+		H1:          root.H1, H2: root.H2, H3: root.H3,
+		InternalLinks:   internal,
+		ExternalLinks:   external,
+		BrokenLinks:     broken,
+		BlockedByRobots: blocked,
+		UncheckedLinks:  unchecked,
+		RedirectedLinks: redirected,
+		HasLogin:        root.HasLogin,
+	})
+	return interrupted
+}
 
-	raw, _ := io.ReadAll(resp.Body)
-	htmlStr := string(raw)
-	version := detectHTMLVersion(htmlStr)
+// failure is the status of a crawl that could not fetch its root page.
+func failure(ctx context.Context) string {
+	if errors.Is(context.Cause(ctx), errCancelled) {
+		return "cancelled"
+	}
+	return "error"
+}
 
-	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(htmlStr))
+// finish closes the run with s.Status and, if any page was crawled,
+// writes the metrics of s to both the run and the URL summary. The done
+// event follows the writes so listeners can reload the URL.
+func (j *crawlJob) finish(s *Summary) {
+	runUpd := map[string]any{"status": s.Status, "finished_at": time.Now()}
+	urlUpd := map[string]any{"crawl_status": s.Status}
+	if s.Pages > 0 {
+		for k, v := range s.fields() {
+			runUpd[k] = v
+			urlUpd[k] = v
+		}
+	}
+	database.DB.Model(j.run).Updates(runUpd)
+	database.DB.Model(j.rec).Updates(urlUpd)
+	j.prog.done(*s)
+	announce(j.rec.ID)
+	if err := alert.Evaluate(j.run.ID); err != nil {
+		log.Printf("run %d: alerts: %v", j.run.ID, err)
+	}
+	if err := webhook.CrawlFinished(j.run.ID); err != nil {
+		log.Printf("run %d: webhooks: %v", j.run.ID, err)
+	}
+}
+
+// interrupt closes the run of a crawl stopped by Shutdown or a lost
+// lease as "interrupted". The URL is left to release or to the worker
+// now holding the job, and no alert or webhook follows: the crawl has
+// not ended. The run's event
+// stream still ends with done so listeners stop following it.
+func (j *crawlJob) interrupt() {
+	database.DB.Model(j.run).Updates(map[string]any{"status": "interrupted", "finished_at": time.Now()})
+	j.prog.done(Summary{Status: "interrupted"})
+}
+
+/*───────────────── crawl one page ──────────────*/
+
+// crawlPage downloads and analyses a single page, checks its links and
+// stores the page and its link rows. It returns the internal http(s)
+// links found on the page so the caller can extend the frontier.
+// report receives the fraction (0–1) of this page that is done.
+func (j *crawlJob) crawlPage(ctx context.Context, task pageTask,
+	report func(float64)) (*models.Page, []string, error) {
 
-	/* 4. headings */
-	h1 := doc.Find("h1").Length()
-	h2 := doc.Find("h2").Length()
-	h3 := doc.Find("h3").Length()
-	Publish(id, 10)
+	/* 1. download page */
+	j.prog.stage(task.url, StageFetching)
+	if !robots.Allowed(ctx, task.url) {
+		return nil, nil, fmt.Errorf("GET %s: %w", task.url, errBlockedByRobots)
+	}
+	doc, version, trace, err := j.fetchPage(ctx, task.url)
+	if task.depth == 0 {
+		j.saveRootRedirects(trace)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
 
-	/* 5. login form */
-	hasLogin := doc.Find("form").FilterFunction(func(_ int, f *goquery.Selection) bool {
+	/* 2. headings */
+	j.prog.stage(task.url, StageParsing)
+	page := models.Page{
+		URLID:       j.rec.ID,
+		RunID:       &j.run.ID,
+		ParentID:    task.parentID,
+		URL:         task.url,
+		Depth:       task.depth,
+		HTMLVersion: &version,
+		Title:       ptr(doc.Find("title").Text()),
+		H1:          doc.Find("h1").Length(),
+		H2:          doc.Find("h2").Length(),
+		H3:          doc.Find("h3").Length(),
+	}
+	report(0.10)
+
+	/* 3. login form */
+	page.HasLogin = doc.Find("form").FilterFunction(func(_ int, f *goquery.Selection) bool {
 		return f.Find(`input[type="password"]`).Length() > 0
 	}).Length() > 0
-	Publish(id, 15)
+	report(0.15)
 
-	/* 6. link extraction & status check */
-	pageHost := host(rec.OriginalURL)
+	/* 4. link extraction */
+	pageHost := host(task.url)
 
-	internal, external, broken := 0, 0, 0
 	var linkRows []models.Link
-
-	links := doc.Find("a[href]")
-	totalSteps := links.Length() + 18 // 18 % done so far
-
-	links.Each(func(i int, s *goquery.Selection) {
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
 		href, _ := s.Attr("href")
 		href = strings.TrimSpace(href)
 		if href == "" || strings.HasPrefix(href, "#") {
 			return
 		}
 
-		abs := absolute(rec.OriginalURL, href)
+		abs := absolute(task.url, href)
 		isInt := host(abs) == pageHost
 		if isInt {
-			internal++
+			page.InternalLinks++
 		} else {
-			external++
-		}
-
-		st := headStatus(ctx, abs)
-		if st >= 400 {
-			broken++
+			page.ExternalLinks++
 		}
-
 		linkRows = append(linkRows, models.Link{
-			URLID:      rec.ID,
+			URLID:      j.rec.ID,
+			RunID:      &j.run.ID,
 			Href:       abs,
-			HTTPStatus: &st,
 			IsInternal: isInt,
 		})
+	})
 
-		Publish(id, percent(i+16, totalSteps))
+	/* 5. concurrent status checks, tallied in document order */
+	totalSteps := len(linkRows) + 18 // 18 % done so far
+	j.prog.stage(task.url, StageChecking)
+	j.checkLinks(ctx, linkRows, func(row *models.Link, done int) {
+		j.prog.link(task.url, row)
+		report(float64(done+16) / float64(totalSteps))
 	})
 
-	/* replace old link rows */
-	database.DB.Where("url_id = ?", rec.ID).Delete(&models.Link{})
-	if len(linkRows) > 0 {
-		database.DB.Create(&linkRows)
+	var next []string
+	for _, row := range linkRows {
+		switch row.CheckStatus {
+		case "blocked_by_robots":
+			page.BlockedByRobots++
+		case "unchecked":
+			page.UncheckedLinks++
+		default:
+			if len(row.Redirects) > 0 {
+				page.RedirectedLinks++
+			}
+			if *row.HTTPStatus >= 400 {
+				page.BrokenLinks++
+			}
+			if row.IsInternal && isHTTP(row.Href) {
+				next = append(next, row.Href)
+			}
+		}
 	}
 
-	/* 7. final update */
-	database.DB.Model(&rec).Updates(models.URL{
-		HTMLVersion: &version,
-		Title:       ptr(doc.Find("title").Text()),
-		H1:          h1, H2: h2, H3: h3,
-		InternalLinks: internal,
-		ExternalLinks: external,
-		BrokenLinks:   broken,
-		HasLogin:      hasLogin,
-		CrawlStatus:   "done",
+	/* 6. store page, then its links; a page without its links is not kept */
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&page).Error; err != nil {
+			return err
+		}
+		for i := range linkRows {
+			linkRows[i].PageID = &page.ID
+		}
+		if len(linkRows) == 0 {
+			return nil
+		}
+		return tx.Create(&linkRows).Error
 	})
-	Publish(id, 100)
+	if err != nil {
+		return nil, nil, fmt.Errorf("store %s: %w", task.url, err)
+	}
+	return &page, next, nil
+}
+
+// fetchPage GETs an HTML page and parses it. The redirect trace is
+// returned even when the fetch fails.
+func (j *crawlJob) fetchPage(ctx context.Context, u string) (*goquery.Document, string, *redirectTrace, error) {
+	ctx, trace := withTrace(ctx)
+
+	release, err := limiter.acquire(ctx, u, j.limits, j.prog.wait)
+	if err != nil {
+		return nil, "", trace, err
+	}
+	defer release()
+
+	req, err := newRequest(ctx, http.MethodGet, u)
+	if err != nil {
+		return nil, "", trace, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", trace, err
+	}
+	defer resp.Body.Close()
+	trace.finish(resp)
+
+	if resp.StatusCode >= 400 {
+		return nil, "", trace, fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		return nil, "", trace, fmt.Errorf("GET %s: not an HTML page", u)
+	}
+
+	// larger pages are cut off and parsed as far as they were read
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, cfg.MaxPageBytes))
+	if err != nil {
+		return nil, "", trace, fmt.Errorf("GET %s: %w", u, err)
+	}
+	htmlStr := string(raw)
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlStr))
+	if err != nil {
+		return nil, "", trace, err
+	}
+	return doc, detectHTMLVersion(htmlStr), trace, nil
+}
+
+// saveRootRedirects stores the redirect chain of the submitted URL and
+// where it ended up.
+func (j *crawlJob) saveRootRedirects(t *redirectTrace) {
+	if len(t.hops) == 0 {
+		return
+	}
+	for i := range t.hops {
+		t.hops[i].URLID = j.rec.ID
+		t.hops[i].RunID = &j.run.ID
+	}
+	database.DB.Create(&t.hops)
+
+	upd := map[string]any{
+		"final_url":     t.hops[len(t.hops)-1].URL,
+		"redirect_loop": t.loop,
+	}
+	database.DB.Model(j.run).Updates(upd)
+	database.DB.Model(j.rec).Updates(upd)
 }
 
 /*───────────────── helpers ─────────────────────*/
 
-func headStatus(ctx context.Context, u string) int {
-	req, _ := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
-	res, err := client.Do(req)
+// newRequest builds a request carrying the configured User-Agent.
+func newRequest(ctx context.Context, method, u string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
 	if err != nil {
-		return 0
+		return nil, err
 	}
-	res.Body.Close()
-	return res.StatusCode
+	req.Header.Set("User-Agent", cfg.UserAgent)
+	return req, nil
 }
 
 func absolute(base, href string) string {
@@ -191,10 +453,35 @@ func absolute(base, href string) string {
 }
 
 func host(u string) string {
-	parsed, _ := url.Parse(u)
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
 	return parsed.Hostname()
 }
 
+func isHTTP(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https")
+}
+
+// normalize drops the fragment so /a and /a#top count as one page.
+func normalize(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	return parsed.String()
+}
+
+// crawlTimeout gives every page the single-page budget of 45s, capped so
+// a large site crawl cannot hold a worker forever.
+func crawlTimeout(pages int) time.Duration {
+	return min(time.Duration(pages)*45*time.Second, 15*time.Minute)
+}
+
 func percent(done, total int) int {
 	if total == 0 {
 		return 100
@@ -202,13 +489,6 @@ func percent(done, total int) int {
 	return int(float64(done) / float64(total) * 100)
 }
 
-func fail(id uint64) {
-	database.DB.Model(&models.URL{}).
-		Where("id = ?", id).
-		Update("crawl_status", "error")
-	Publish(id, 100)
-}
-
 func ptr[T any](v T) *T { return &v }
 
 /*──────────── HTML version util ────────────*/
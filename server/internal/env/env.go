@@ -0,0 +1,33 @@
+// Package env reads the optional settings shared by cmd/api and
+// cmd/worker from the environment.
+package env
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Int reads an optional integer setting; unset or invalid means 0.
+func Int(key string) int {
+	n, _ := strconv.Atoi(os.Getenv(key))
+	return n
+}
+
+// Duration reads an optional time.Duration setting; unset or invalid means 0.
+func Duration(key string) time.Duration {
+	d, _ := time.ParseDuration(os.Getenv(key))
+	return d
+}
+
+// List reads an optional comma-separated setting.
+func List(key string) []string {
+	var list []string
+	for _, s := range strings.Split(os.Getenv(key), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+/* ───────────── Alert rules & alerts ─────────────────── */
+
+// AlertRule fires when Metric of a finished crawl compares to Threshold
+// as Op says, e.g. broken_links > 0. A rule without a URLID applies to
+// every URL of its user.
+type AlertRule struct {
+	ID        uint64    `gorm:"primaryKey"        json:"id"`
+	UserID    uint64    `gorm:"not null;index"    json:"-"`
+	URLID     *uint64   `gorm:"index"             json:"url_id"` // nil = all the user's URLs
+	Metric    string    `gorm:"size:32;not null"  json:"metric"`
+	Op        string    `gorm:"size:2;not null"   json:"op"` // > >= < <= == !=
+	Threshold int       `json:"threshold"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Alert is a rule that fired for a URL. While it is unresolved, later
+// crawls that still trip the rule update it rather than open another.
+// Metric, Op and Threshold are copied from the rule so the alert still
+// reads right after the rule is changed or deleted.
+type Alert struct {
+	ID             uint64          `gorm:"primaryKey"                 json:"id"`
+	RuleID         uint64          `gorm:"not null;index"             json:"rule_id"`
+	UserID         uint64          `gorm:"not null;index"             json:"-"`
+	URLID          uint64          `gorm:"not null;index"             json:"url_id"`
+	RunID          uint64          `gorm:"not null"                   json:"run_id"` // latest crawl that tripped the rule
+	Metric         string          `gorm:"size:32;not null"           json:"metric"`
+	Op             string          `gorm:"size:2;not null"            json:"op"`
+	Threshold      int             `json:"threshold"`
+	Value          int             `json:"value"`                                   // Metric in RunID
+	State          string          `gorm:"size:16;default:open;index" json:"state"` // open | acknowledged | resolved
+	AcknowledgedAt *time.Time      `json:"acknowledged_at"`
+	ResolvedAt     *time.Time      `json:"resolved_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Evidence       []AlertEvidence `gorm:"foreignKey:AlertID"         json:"evidence,omitempty"`
+}
+
+// AlertEvidence is one link of the run that made the alert fire.
+type AlertEvidence struct {
+	ID         uint64 `gorm:"primaryKey"     json:"-"`
+	AlertID    uint64 `gorm:"not null;index" json:"-"`
+	Href       string `gorm:"size:2048"      json:"href"`
+	HTTPStatus *int   `json:"http_status"`
+	ErrorKind  string `gorm:"size:32"        json:"error_kind,omitempty"`
+	IsInternal bool   `json:"is_internal"`
+}
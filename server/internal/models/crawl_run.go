@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+/* ───────────── Crawl runs table ─────────────────────── */
+
+// CrawlRun is an immutable snapshot of one crawl of a URL. Pages, links
+// and redirects carry the RunID they were found in; URL.LatestRunID
+// points at the newest run and URL repeats its metrics for listing.
+type CrawlRun struct {
+	ID              uint64     `gorm:"primaryKey"            json:"id"`
+	URLID           uint64     `gorm:"not null;index"        json:"url_id"`
+	Status          string     `gorm:"size:16;default:running" json:"status"` // running | done | error | cancelled | interrupted (by a shutdown; crawled again)
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+	HTMLVersion     *string    `json:"html_version"`
+	Title           *string    `json:"title"`
+	H1              int        `json:"h1"`
+	H2              int        `json:"h2"`
+	H3              int        `json:"h3"`
+	InternalLinks   int        `json:"internal_links"`
+	ExternalLinks   int        `json:"external_links"`
+	BrokenLinks     int        `json:"broken_links"`
+	BlockedByRobots int        `json:"blocked_by_robots"`
+	UncheckedLinks  int        `json:"unchecked_links"`
+	RedirectedLinks int        `json:"redirected_links"`
+	FinalURL        *string    `gorm:"size:2048" json:"final_url"`
+	RedirectLoop    bool       `json:"redirect_loop"`
+	HasLogin        bool       `json:"has_login"`
+	Links           []Link     `gorm:"foreignKey:RunID" json:"links,omitempty"`
+	Pages           []Page     `gorm:"foreignKey:RunID" json:"pages,omitempty"`
+	Redirects       []Redirect `gorm:"foreignKey:RunID" json:"redirects,omitempty"` // chain of the submitted URL
+}
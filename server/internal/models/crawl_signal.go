@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+/* ───────────── Broker signals ───────────────────────── */
+
+// CrawlSignal is one message relayed through crawler.DBBroker: an event
+// of a running crawl, a status change of a URL or a request to cancel a
+// crawl.
+type CrawlSignal struct {
+	ID        uint64    `gorm:"primaryKey"`
+	URLID     uint64    `gorm:"not null;index"`
+	UserID    uint64    `gorm:"not null;default:0;index"` // owner of the URL; 0 on cancel
+	Kind      string    `gorm:"size:16;not null"`         // event | status | cancel
+	Payload   string    `gorm:"type:text"`                // event and status: the crawler.Event as JSON
+	CreatedAt time.Time `gorm:"index"`
+}
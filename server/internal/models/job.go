@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+/* ───────────── Crawl job queue ──────────────────────── */
+
+// Job is one queued crawl of a URL. Workers claim queued jobs by taking a
+// lease (WorkerID + LeaseUntil) and renew it while crawling; a job whose
+// lease has run out is treated as queued again.
+type Job struct {
+	ID                uint64     `gorm:"primaryKey"                  json:"id"`
+	URLID             uint64     `gorm:"not null;index"              json:"url_id"`
+	UserID            uint64     `gorm:"not null;index:idx_jobs_user_claimed" json:"user_id"`
+	Priority          int        `gorm:"not null;default:0;index"    json:"priority"` // crawler.Priority, higher runs first
+	State             string     `gorm:"size:16;default:queued;index" json:"state"`   // queued | leased | done | failed | drained | cancelled
+	Attempts          int        `json:"attempts"`
+	WorkerID          *string    `gorm:"size:128" json:"worker_id"`
+	ClaimedAt         *time.Time `gorm:"index:idx_jobs_user_claimed" json:"claimed_at"` // last lease taken; drives per-user round-robin
+	LeaseUntil        *time.Time `json:"lease_until"`
+	CancelRequestedAt *time.Time `json:"cancel_requested_at"` // set by crawler.Cancel; the worker stops the crawl
+	LastError         *string    `gorm:"size:1024" json:"last_error"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+/* ───────────── Runtime settings ─────────────────────── */
+
+// Setting is a process-wide switch shared by every API and worker
+// process, e.g. "queue_paused" = "true".
+type Setting struct {
+	Key   string `gorm:"primaryKey;size:64"`
+	Value string `gorm:"size:255"`
+}
@@ -5,31 +5,100 @@ import "time"
 /* ───────────── URLs table ───────────────────────────── */
 
 type URL struct {
-	ID            uint64    `gorm:"primaryKey"            json:"id"`
-	UserID        uint64    `gorm:"not null;index" json:"-"`
-	OriginalURL   string    `gorm:"size:768;uniqueIndex:idx_urls_user_url" json:"original_url"`
-	CrawlStatus   string    `gorm:"default:queued"        json:"crawl_status"` // queued | running | done | error
-	HTMLVersion   *string   `json:"html_version"`
-	Title         *string   `json:"title"`
-	H1            int       `json:"h1"`
-	H2            int       `json:"h2"`
-	H3            int       `json:"h3"`
-	InternalLinks int       `json:"internal_links"`
-	ExternalLinks int       `json:"external_links"`
-	BrokenLinks   int       `json:"broken_links"`
-	HasLogin      bool      `json:"has_login"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	Links         []Link    `json:"links"` // one-to-many
+	ID              uint64     `gorm:"primaryKey"            json:"id"`
+	UserID          uint64     `gorm:"not null;index;uniqueIndex:idx_urls_user_url,priority:1" json:"-"`
+	OriginalURL     string     `gorm:"size:768;uniqueIndex:idx_urls_user_url,priority:2" json:"original_url"`
+	CrawlStatus     string     `gorm:"default:queued"        json:"crawl_status"` // queued | running | done | error | cancelled
+	MaxDepth        int        `gorm:"default:0"             json:"max_depth"`    // 0 = root page only
+	MaxPages        int        `gorm:"default:1"             json:"max_pages"`
+	HostConcurrency int        `json:"host_concurrency"`         // 0 = server default
+	HostIntervalMs  int        `json:"host_interval_ms"`         // 0 = server default
+	LatestRunID     *uint64    `json:"latest_run_id"`            // newest CrawlRun, nil before the first crawl
+	Schedule        *string    `gorm:"size:128" json:"schedule"` // cron expression or "@every 6h", nil = manual only
+	SchedulePaused  bool       `json:"schedule_paused"`
+	NextRunAt       *time.Time `gorm:"index" json:"next_run_at"`
+	LastRunAt       *time.Time `json:"last_run_at"`
+	HTMLVersion     *string    `json:"html_version"`
+	Title           *string    `json:"title"`
+	H1              int        `json:"h1"`
+	H2              int        `json:"h2"`
+	H3              int        `json:"h3"`
+	InternalLinks   int        `json:"internal_links"`
+	ExternalLinks   int        `json:"external_links"`
+	BrokenLinks     int        `json:"broken_links"`
+	BlockedByRobots int        `json:"blocked_by_robots"`
+	UncheckedLinks  int        `json:"unchecked_links"` // left when the crawl deadline fired
+	RedirectedLinks int        `json:"redirected_links"`
+	FinalURL        *string    `gorm:"size:2048" json:"final_url"` // where OriginalURL redirects to, nil if it doesn't
+	RedirectLoop    bool       `json:"redirect_loop"`
+	HasLogin        bool       `json:"has_login"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Links           []Link     `json:"links"`                                       // of the latest run
+	Pages           []Page     `json:"pages,omitempty"`                             // of the latest run (tree in detail view)
+	Redirects       []Redirect `gorm:"foreignKey:URLID" json:"redirects,omitempty"` // chain of OriginalURL in the latest run
+}
+
+/* ───────────── Pages table ──────────────────────────── */
+
+// Page holds the analysis of one crawled page of a site crawl.
+// The root page has Depth 0 and no parent.
+type Page struct {
+	ID              uint64    `gorm:"primaryKey"      json:"id"`
+	URLID           uint64    `gorm:"not null;index"  json:"-"`
+	RunID           *uint64   `gorm:"index"           json:"run_id"`
+	ParentID        *uint64   `json:"parent_id"`
+	URL             string    `gorm:"size:2048"       json:"url"`
+	Depth           int       `json:"depth"`
+	HTMLVersion     *string   `json:"html_version"`
+	Title           *string   `json:"title"`
+	H1              int       `json:"h1"`
+	H2              int       `json:"h2"`
+	H3              int       `json:"h3"`
+	InternalLinks   int       `json:"internal_links"`
+	ExternalLinks   int       `json:"external_links"`
+	BrokenLinks     int       `json:"broken_links"`
+	BlockedByRobots int       `json:"blocked_by_robots"`
+	UncheckedLinks  int       `json:"unchecked_links"` // left when the crawl deadline fired
+	RedirectedLinks int       `json:"redirected_links"`
+	HasLogin        bool      `json:"has_login"`
+	CreatedAt       time.Time `json:"created_at"`
+	Children        []Page    `gorm:"-"               json:"children,omitempty"`
 }
 
 /* ───────────── Links table ──────────────────────────── */
 
 type Link struct {
-	ID         uint64     `gorm:"primaryKey"      json:"-"`
-	URLID      uint64     `json:"-"`
-	Href       string     `gorm:"size:2048"       json:"href"`
-	HTTPStatus *int       `gorm:"column:http_status" json:"http_status"` // nil until checked
-	IsInternal bool       `json:"is_internal"`
-	CheckedAt  *time.Time `json:"checked_at"`
+	ID           uint64     `gorm:"primaryKey"      json:"-"`
+	URLID        uint64     `json:"-"`
+	RunID        *uint64    `gorm:"index"           json:"run_id"`
+	PageID       *uint64    `gorm:"index"           json:"page_id"`
+	Href         string     `gorm:"size:2048"       json:"href"`
+	HTTPStatus   *int       `gorm:"column:http_status" json:"http_status"` // nil until checked
+	IsInternal   bool       `json:"is_internal"`
+	Method       string     `gorm:"size:8" json:"method"`                        // HEAD, or GET after fallback
+	Attempts     int        `json:"attempts"`                                    // requests made, retries included
+	CheckStatus  string     `gorm:"size:32;default:checked" json:"check_status"` // checked | blocked_by_robots | unchecked
+	ErrorKind    string     `gorm:"size:32;index" json:"error_kind"`             // dns | tls | timeout | refused | reset | too_many_redirects | invalid_url | blocked | other
+	ErrorMessage string     `gorm:"size:1024" json:"error_message"`
+	RedirectLoop bool       `json:"redirect_loop"`
+	LongRedirect bool       `json:"long_redirect"` // more hops than the configured limit
+	CheckedAt    *time.Time `json:"checked_at"`
+	Redirects    []Redirect `json:"redirects,omitempty"`
+}
+
+/* ───────────── Redirects table ──────────────────────── */
+
+// Redirect is one hop of a redirect chain. Rows with a LinkID belong to a
+// checked link; rows without one trace the submitted URL itself. The last
+// hop of a chain is the final response and has no Location.
+type Redirect struct {
+	ID       uint64  `gorm:"primaryKey"     json:"-"`
+	URLID    uint64  `gorm:"not null;index" json:"-"`
+	RunID    *uint64 `gorm:"index"          json:"-"`
+	LinkID   *uint64 `gorm:"index"          json:"-"`
+	Hop      int     `json:"hop"`
+	URL      string  `gorm:"size:2048"      json:"url"`
+	Status   int     `json:"status"`
+	Location string  `gorm:"size:2048"      json:"location"`
 }
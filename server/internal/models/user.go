@@ -4,4 +4,5 @@ type User struct {
 	ID       uint64 `gorm:"primaryKey"`
 	Email    string
 	PassHash string
+	IsAdmin  bool // may use the /queue admin endpoints; set directly in the DB
 }
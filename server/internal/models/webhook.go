@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+/* ───────────── Webhooks ─────────────────────────────── */
+
+// Webhook is an endpoint a user wants told about finished crawls. Events
+// is a comma-separated list of webhook event names (done, error,
+// broken_links_increased).
+type Webhook struct {
+	ID        uint64    `gorm:"primaryKey"            json:"id"`
+	UserID    uint64    `gorm:"not null;index"        json:"-"`
+	URL       string    `gorm:"size:2048;not null"    json:"url"`
+	Secret    string    `gorm:"size:64;not null"      json:"-"` // HMAC key, shown once on create
+	Events    string    `gorm:"size:128;not null"     json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event to be sent to a webhook. The dispatcher
+// retries it with exponential backoff until it is delivered or has used
+// up its attempts.
+type WebhookDelivery struct {
+	ID            uint64           `gorm:"primaryKey"                    json:"id"`
+	WebhookID     uint64           `gorm:"not null;index"                json:"webhook_id"`
+	URLID         uint64           `gorm:"not null"                      json:"url_id"`
+	RunID         uint64           `gorm:"not null"                      json:"run_id"`
+	Event         string           `gorm:"size:32;not null"              json:"event"`
+	Payload       string           `gorm:"type:text;not null"            json:"-"`
+	State         string           `gorm:"size:16;default:pending;index" json:"state"` // pending | delivered | failed
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt time.Time        `gorm:"index"                         json:"next_attempt_at"`
+	DeliveredAt   *time.Time       `json:"delivered_at"`
+	CreatedAt     time.Time        `json:"created_at"`
+	AttemptLog    []WebhookAttempt `gorm:"foreignKey:DeliveryID"         json:"attempt_log,omitempty"`
+}
+
+// WebhookAttempt records one POST of a delivery.
+type WebhookAttempt struct {
+	ID         uint64    `gorm:"primaryKey"     json:"-"`
+	DeliveryID uint64    `gorm:"not null;index" json:"-"`
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"status_code"` // nil when no response came back
+	Error      string    `gorm:"size:1024" json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
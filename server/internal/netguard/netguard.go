@@ -0,0 +1,173 @@
+// Package netguard keeps outbound requests made on users' behalf (page
+// fetches, link checks, webhook deliveries) away from internal networks.
+//
+// Every connection goes through Dial. It resolves the host itself,
+// refuses it if any address is private, loopback, link-local (cloud
+// metadata included) or otherwise not on the public internet, and then
+// dials the checked IP rather than the name. Redirects dial through it
+// like any request, and a DNS answer that changes between check and
+// connect is never looked up a second time. Allow exempts hosts, IPs and
+// CIDRs that are meant to be reached although they are internal.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBlocked is wrapped by the errors of refused connections.
+var ErrBlocked = errors.New("address not allowed")
+
+// blockedNets are the ranges no connection may go to.
+var blockedNets = func() []netip.Prefix {
+	var nets []netip.Prefix
+	for _, s := range []string{
+		"0.0.0.0/8",      // "this" network
+		"10.0.0.0/8",     // RFC 1918
+		"100.64.0.0/10",  // carrier-grade NAT
+		"127.0.0.0/8",    // loopback
+		"169.254.0.0/16", // link-local, incl. 169.254.169.254 metadata
+		"172.16.0.0/12",  // RFC 1918
+		"192.0.0.0/24",   // IETF protocol assignments, incl. 192.0.0.192 metadata
+		"192.168.0.0/16", // RFC 1918
+		"198.18.0.0/15",  // benchmarking
+		"224.0.0.0/4",    // multicast
+		"240.0.0.0/4",    // reserved, incl. broadcast
+		"::/128",         // unspecified
+		"::1/128",        // loopback
+		"64:ff9b::/96",   // NAT64, embeds an IPv4 address
+		"2001::/32",      // Teredo, embeds an IPv4 address
+		"2002::/16",      // 6to4, embeds an IPv4 address
+		"fc00::/7",       // unique local, incl. fd00:ec2::254 metadata
+		"fe80::/10",      // link-local
+		"ff00::/8",       // multicast
+	} {
+		nets = append(nets, netip.MustParsePrefix(s))
+	}
+	return nets
+}()
+
+// allowlist holds the parsed Allow entries.
+type allowlist struct {
+	hosts map[string]bool
+	nets  []netip.Prefix
+}
+
+func newAllowlist(entries []string) *allowlist {
+	a := &allowlist{hosts: map[string]bool{}}
+	for _, e := range entries {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if p, err := netip.ParsePrefix(e); err == nil {
+			a.nets = append(a.nets, p.Masked())
+		} else if ip, err := netip.ParseAddr(e); err == nil {
+			a.nets = append(a.nets, netip.PrefixFrom(ip, ip.BitLen()))
+		} else if e != "" {
+			a.hosts[strings.TrimSuffix(e, ".")] = true
+		}
+	}
+	return a
+}
+
+func (a *allowlist) host(h string) bool {
+	return a.hosts[strings.TrimSuffix(strings.ToLower(h), ".")]
+}
+
+func (a *allowlist) ip(ip netip.Addr) bool {
+	for _, p := range a.nets {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var allowed atomic.Pointer[allowlist]
+
+func init() { allowed.Store(newAllowlist(nil)) }
+
+// Allow replaces the hosts, IPs and CIDRs exempt from the guard.
+func Allow(entries []string) { allowed.Store(newAllowlist(entries)) }
+
+// lookupIP resolves a host name; IP literals come back as they are.
+var lookupIP = net.DefaultResolver.LookupNetIP
+
+var dialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+// Transport returns a copy of http.DefaultTransport that dials through
+// the guard. It uses no proxy, as a proxy would connect on the caller's
+// behalf unchecked.
+func Transport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = Dial
+	return t
+}
+
+// Dial connects to addr's first reachable address after checking them
+// all.
+func Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if allowed.Load().host(host) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := lookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIPs(host, ips); err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// checkIPs fails if any of host's addresses is blocked and not allowed.
+func checkIPs(host string, ips []netip.Addr) error {
+	a := allowed.Load()
+	for _, ip := range ips {
+		ip = ip.Unmap()
+		if blockedIP(ip) && !a.ip(ip) {
+			return fmt.Errorf("%s resolves to %s: %w", host, ip, ErrBlocked)
+		}
+	}
+	return nil
+}
+
+func blockedIP(ip netip.Addr) bool {
+	for _, p := range blockedNets {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckHost returns an error if Dial would refuse host. A host that does
+// not resolve passes; connecting to it reports that.
+func CheckHost(ctx context.Context, host string) error {
+	if allowed.Load().host(host) {
+		return nil
+	}
+	ips, err := lookupIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	return checkIPs(host, ips)
+}
@@ -0,0 +1,92 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestBlockedIP(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"127.0.0.1":         true,
+		"10.1.2.3":          true,
+		"172.20.0.1":        true,
+		"192.168.1.1":       true,
+		"169.254.169.254":   true,
+		"100.64.0.1":        true,
+		"0.0.0.0":           true,
+		"::1":               true,
+		"fd00:ec2::254":     true,
+		"fe80::1":           true,
+		"64:ff9b::7f00:1":   true, // NAT64 of 127.0.0.1
+		"2002:a9fe:a9fe::1": true, // 6to4 of 169.254.169.254
+		"2001:0:a9fe::1":    true, // Teredo
+		"93.184.216.34":     false,
+		"172.32.0.1":        false,
+		"2606:4700:4700::1": false,
+	} {
+		if got := blockedIP(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("blockedIP(%s) = %v; want %v", ip, got, want)
+		}
+	}
+	// IPv4-mapped IPv6 is unmapped before the check
+	if checkIPs("x", []netip.Addr{netip.MustParseAddr("::ffff:127.0.0.1")}) == nil {
+		t.Error("::ffff:127.0.0.1 passed the guard")
+	}
+}
+
+func TestDialRefusesInternalAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	defer func(l func(context.Context, string, string) ([]netip.Addr, error)) { lookupIP = l }(lookupIP)
+	lookupIP = func(ctx context.Context, network, host string) ([]netip.Addr, error) {
+		if host == "rebind.example" { // a public name answering with loopback
+			return []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("127.0.0.1")}, nil
+		}
+		return net.DefaultResolver.LookupNetIP(ctx, network, host)
+	}
+
+	client := &http.Client{Transport: Transport()}
+	get := func(u string) error {
+		res, err := client.Get(u)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	if err := get("http://rebind.example/"); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("name resolving to loopback: err = %v; want blocked", err)
+	}
+	if err := get(srv.URL + "/"); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("loopback: err = %v; want blocked", err)
+	}
+	if CheckHost(context.Background(), "127.0.0.1") == nil || CheckHost(context.Background(), "localhost") == nil {
+		t.Fatal("CheckHost let loopback through")
+	}
+
+	// allowed explicitly, loopback is reachable
+	defer Allow(nil)
+	Allow([]string{"127.0.0.1"})
+	if err := get(srv.URL + "/"); err != nil {
+		t.Fatalf("allowed loopback: %v", err)
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	a := newAllowlist([]string{"Intranet.Local.", "10.1.0.0/16", "192.168.5.5", " "})
+	if !a.host("intranet.local") || a.host("other.local") {
+		t.Error("host entries")
+	}
+	if !a.ip(netip.MustParseAddr("10.1.2.3")) || a.ip(netip.MustParseAddr("10.2.0.1")) {
+		t.Error("CIDR entries")
+	}
+	if !a.ip(netip.MustParseAddr("192.168.5.5")) || a.ip(netip.MustParseAddr("192.168.5.6")) {
+		t.Error("IP entries")
+	}
+}
@@ -15,7 +15,13 @@ func InitInMemoryDB() {
 	if err != nil {
 		log.Fatalf("sqlite open: %v", err)
 	}
-	// create only the tables needed for auth tests
-	_ = db.AutoMigrate(&models.User{})
+	// every connection to :memory: is a fresh database, so keep just one
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	_ = db.AutoMigrate(&models.User{}, &models.URL{}, &models.Page{}, &models.Link{}, &models.Redirect{},
+		&models.CrawlRun{}, &models.Job{}, &models.Setting{}, &models.CrawlSignal{},
+		&models.Webhook{}, &models.WebhookDelivery{}, &models.WebhookAttempt{},
+		&models.AlertRule{}, &models.Alert{}, &models.AlertEvidence{})
 	database.DB = db
 }
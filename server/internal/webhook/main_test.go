@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"os"
+	"testing"
+
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+)
+
+// The tests deliver to httptest servers on loopback, which the guard
+// refuses unless allowed.
+func TestMain(m *testing.M) {
+	netguard.Allow([]string{"127.0.0.1"})
+	os.Exit(m.Run())
+}
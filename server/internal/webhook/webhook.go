@@ -0,0 +1,287 @@
+// Package webhook tells users' endpoints about finished crawls. A
+// finishing crawl records one delivery per interested webhook
+// (CrawlFinished); Dispatcher POSTs them, signed with the webhook's
+// secret, and retries failures with exponential backoff. Every POST is
+// kept as a WebhookAttempt so users can see what happened.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+)
+
+// Events a webhook can listen to.
+const (
+	EventDone                 = "done"                   // a crawl finished
+	EventError                = "error"                  // a crawl failed
+	EventBrokenLinksIncreased = "broken_links_increased" // a finished crawl found more broken links than the previous one
+)
+
+// Events lists every event name, for validation.
+var Events = []string{EventDone, EventError, EventBrokenLinksIncreased}
+
+// Headers of a delivery. The signature is "sha256=" followed by the hex
+// HMAC-SHA256 of the body keyed with the webhook's secret.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var (
+	maxAttempts = 6                // POSTs per delivery before it is failed
+	backoffBase = 30 * time.Second // wait after the first failure, doubled after each
+	backoffMax  = time.Hour
+	claimTTL    = time.Minute // how long a claimed delivery is left to its dispatcher
+	senders     = 4           // deliveries POSTed at once
+
+	// deliveries dial through the guard: endpoints are user-supplied URLs
+	client = &http.Client{Timeout: 10 * time.Second, Transport: netguard.Transport()}
+)
+
+// wake nudges the dispatcher after CrawlFinished so deliveries go out
+// without waiting for the next tick.
+var wake = make(chan struct{}, 1)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Event               string          `json:"event"`
+	URLID               uint64          `json:"url_id"`
+	URL                 string          `json:"url"`
+	Run                 models.CrawlRun `json:"run"`
+	PreviousBrokenLinks *int            `json:"previous_broken_links,omitempty"` // broken_links_increased only
+}
+
+// Sign returns the signature header value of body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Listens reports whether h is subscribed to event.
+func Listens(h *models.Webhook, event string) bool {
+	return slices.Contains(strings.Split(h.Events, ","), event)
+}
+
+/*──────────────── recording deliveries ────────────────*/
+
+// CrawlFinished records the deliveries that run's outcome triggers for
+// the webhooks of its URL's owner.
+func CrawlFinished(runID uint64) error {
+	var run models.CrawlRun
+	if err := database.DB.First(&run, runID).Error; err != nil {
+		return err
+	}
+	var u models.URL
+	if err := database.DB.Select("id", "user_id", "original_url").First(&u, run.URLID).Error; err != nil {
+		return err
+	}
+
+	var hooks []models.Webhook
+	if err := database.DB.Where("user_id = ?", u.UserID).Find(&hooks).Error; err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	var payloads []Payload
+	switch run.Status {
+	case "done":
+		payloads = append(payloads, Payload{Event: EventDone})
+		var prev models.CrawlRun
+		res := database.DB.
+			Where("url_id = ? AND id < ? AND status = ?", run.URLID, run.ID, "done").
+			Order("id DESC").Limit(1).Find(&prev)
+		if res.Error == nil && res.RowsAffected == 1 && run.BrokenLinks > prev.BrokenLinks {
+			payloads = append(payloads, Payload{Event: EventBrokenLinksIncreased, PreviousBrokenLinks: &prev.BrokenLinks})
+		}
+	case "error":
+		payloads = append(payloads, Payload{Event: EventError})
+	default:
+		return nil
+	}
+
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	for _, p := range payloads {
+		p.URLID, p.URL, p.Run = u.ID, u.OriginalURL, run
+		body, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		for _, h := range hooks {
+			if Listens(&h, p.Event) {
+				deliveries = append(deliveries, models.WebhookDelivery{
+					WebhookID: h.ID, URLID: u.ID, RunID: run.ID, Event: p.Event,
+					Payload: string(body), State: "pending", NextAttemptAt: now,
+				})
+			}
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := database.DB.Create(&deliveries).Error; err != nil {
+		return err
+	}
+
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+/*──────────────── dispatcher ────────────────*/
+
+// Dispatcher sends due deliveries every tick, and when CrawlFinished
+// records new ones, until ctx is done. Several processes may run it; a
+// delivery is claimed before it is sent.
+func Dispatcher(ctx context.Context, tick time.Duration) {
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-wake:
+		}
+		deliverDue(time.Now())
+	}
+}
+
+// deliverDue claims and sends the pending deliveries due at now.
+func deliverDue(now time.Time) {
+	var due []models.WebhookDelivery
+	if err := database.DB.
+		Where("state = ? AND next_attempt_at <= ?", "pending", now).
+		Order("next_attempt_at").Limit(100).Find(&due).Error; err != nil {
+		log.Println("webhooks:", err)
+		return
+	}
+
+	sem := make(chan struct{}, senders)
+	var wg sync.WaitGroup
+	for i := range due {
+		d := &due[i]
+		// moving next_attempt_at claims it; whoever moves it first sends it
+		res := database.DB.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND state = ? AND next_attempt_at = ?", d.ID, "pending", d.NextAttemptAt).
+			Update("next_attempt_at", now.Add(claimTTL))
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			deliver(d)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver POSTs d once and records the attempt and the outcome.
+func deliver(d *models.WebhookDelivery) {
+	var hook models.Webhook
+	if err := database.DB.First(&hook, d.WebhookID).Error; err != nil {
+		database.DB.Model(d).Update("state", "failed") // webhook deleted meanwhile
+		return
+	}
+
+	attempt := models.WebhookAttempt{DeliveryID: d.ID, Attempt: d.Attempts + 1}
+	start := time.Now()
+	status, err := post(&hook, d)
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if status > 0 {
+		attempt.StatusCode = &status
+	}
+	if err != nil {
+		attempt.Error = truncate(err.Error(), 1024)
+	}
+	database.DB.Create(&attempt)
+
+	upd := map[string]any{"attempts": attempt.Attempt}
+	switch {
+	case err == nil:
+		upd["state"], upd["delivered_at"] = "delivered", time.Now()
+	case attempt.Attempt >= maxAttempts, errors.Is(err, netguard.ErrBlocked):
+		upd["state"] = "failed"
+	default:
+		upd["next_attempt_at"] = time.Now().Add(backoff(attempt.Attempt))
+	}
+	database.DB.Model(d).Updates(upd)
+}
+
+// post sends d's payload to hook. Any response outside 2xx is an error.
+func post(hook *models.Webhook, d *models.WebhookDelivery) (int, error) {
+	body := []byte(d.Payload)
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(d.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &statusError{resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+type statusError struct{ code int }
+
+func (e *statusError) Error() string { return "endpoint answered " + strconv.Itoa(e.code) }
+
+// backoff is the wait after the n-th failed attempt.
+func backoff(n int) time.Duration {
+	d := backoffBase << (n - 1)
+	if d <= 0 || d > backoffMax {
+		return backoffMax
+	}
+	return d
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeewaqar/web-crawler/server/internal/database"
+	"github.com/zeewaqar/web-crawler/server/internal/models"
+	"github.com/zeewaqar/web-crawler/server/internal/netguard"
+	"github.com/zeewaqar/web-crawler/server/internal/test"
+)
+
+func TestCrawlFinishedRecordsMatchingEvents(t *testing.T) {
+	test.InitInMemoryDB()
+
+	u := models.URL{OriginalURL: "https://example.com", UserID: 1, CrawlStatus: "done"}
+	database.DB.Create(&u)
+	database.DB.Create(&models.CrawlRun{URLID: u.ID, Status: "done", BrokenLinks: 1})
+	run := models.CrawlRun{URLID: u.ID, Status: "done", BrokenLinks: 3}
+	database.DB.Create(&run)
+
+	all := models.Webhook{UserID: 1, URL: "http://a", Secret: "s", Events: "done,error,broken_links_increased"}
+	errOnly := models.Webhook{UserID: 1, URL: "http://b", Secret: "s", Events: "error"}
+	foreign := models.Webhook{UserID: 2, URL: "http://c", Secret: "s", Events: "done"}
+	database.DB.Create(&all)
+	database.DB.Create(&errOnly)
+	database.DB.Create(&foreign)
+
+	if err := CrawlFinished(run.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []models.WebhookDelivery
+	database.DB.Order("id").Find(&got)
+	if len(got) != 2 || got[0].Event != EventDone || got[1].Event != EventBrokenLinksIncreased {
+		t.Fatalf("deliveries = %+v, want done and broken_links_increased", got)
+	}
+	for _, d := range got {
+		if d.WebhookID != all.ID || d.State != "pending" {
+			t.Fatalf("delivery %+v should be pending for webhook %d", d, all.ID)
+		}
+	}
+	var p Payload
+	json.Unmarshal([]byte(got[1].Payload), &p)
+	if p.URL != u.OriginalURL || p.Run.ID != run.ID || p.PreviousBrokenLinks == nil || *p.PreviousBrokenLinks != 1 {
+		t.Fatalf("payload = %+v", p)
+	}
+}
+
+func TestDeliverSignsAndRetries(t *testing.T) {
+	test.InitInMemoryDB()
+	defer func(b time.Duration, n int) { backoffBase, maxAttempts = b, n }(backoffBase, maxAttempts)
+	backoffBase, maxAttempts = time.Minute, 3
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("topsecret", body) || r.Header.Get(EventHeader) != "done" {
+			t.Errorf("bad signature or event header: %v", r.Header)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	h := models.Webhook{UserID: 1, URL: srv.URL, Secret: "topsecret", Events: "done"}
+	database.DB.Create(&h)
+	now := time.Now()
+	d := models.WebhookDelivery{WebhookID: h.ID, URLID: 1, RunID: 1, Event: "done",
+		Payload: `{"event":"done"}`, State: "pending", NextAttemptAt: now}
+	database.DB.Create(&d)
+
+	// first attempt fails and is rescheduled a backoff later
+	deliverDue(now)
+	database.DB.First(&d, d.ID)
+	if d.State != "pending" || d.Attempts != 1 || d.NextAttemptAt.Before(now.Add(50*time.Second)) {
+		t.Fatalf("after failure: %+v", d)
+	}
+	deliverDue(now) // not due yet
+	if calls.Load() != 1 {
+		t.Fatalf("retried before the backoff: %d calls", calls.Load())
+	}
+
+	// second attempt succeeds
+	deliverDue(now.Add(2 * time.Minute))
+	database.DB.Preload("AttemptLog").First(&d, d.ID)
+	if d.State != "delivered" || d.Attempts != 2 || d.DeliveredAt == nil {
+		t.Fatalf("after success: %+v", d)
+	}
+	if len(d.AttemptLog) != 2 || *d.AttemptLog[0].StatusCode != 500 || d.AttemptLog[0].Error == "" ||
+		*d.AttemptLog[1].StatusCode != 200 {
+		t.Fatalf("attempt log = %+v", d.AttemptLog)
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	test.InitInMemoryDB()
+	defer func(b time.Duration, n int) { backoffBase, maxAttempts = b, n }(backoffBase, maxAttempts)
+	backoffBase, maxAttempts = time.Millisecond, 2
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	h := models.Webhook{UserID: 1, URL: srv.URL, Secret: "s", Events: "error"}
+	database.DB.Create(&h)
+	d := models.WebhookDelivery{WebhookID: h.ID, Event: "error", Payload: "{}", State: "pending", NextAttemptAt: time.Now()}
+	database.DB.Create(&d)
+
+	deliverDue(time.Now())
+	deliverDue(time.Now().Add(time.Hour))
+	database.DB.First(&d, d.ID)
+	if d.State != "failed" || d.Attempts != 2 {
+		t.Fatalf("delivery = %+v, want failed after 2 attempts", d)
+	}
+}
+
+func TestDeliverRefusesInternalEndpoints(t *testing.T) {
+	test.InitInMemoryDB()
+	defer netguard.Allow([]string{"127.0.0.1"})
+	netguard.Allow(nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("delivery reached a loopback endpoint")
+	}))
+	defer srv.Close()
+
+	for _, target := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/"} {
+		h := models.Webhook{UserID: 1, URL: target, Secret: "s", Events: "done"}
+		database.DB.Create(&h)
+		d := models.WebhookDelivery{WebhookID: h.ID, Event: "done", Payload: "{}", State: "pending", NextAttemptAt: time.Now()}
+		database.DB.Create(&d)
+		deliver(&d)
+
+		var a models.WebhookAttempt
+		database.DB.Where("delivery_id = ?", d.ID).First(&a)
+		if a.StatusCode != nil || !strings.Contains(a.Error, "address not allowed") {
+			t.Fatalf("%s: attempt = %+v; want refused", target, a)
+		}
+		database.DB.First(&d, d.ID)
+		if d.State != "failed" {
+			t.Fatalf("%s: delivery state %q; refused deliveries are not retried", target, d.State)
+		}
+	}
+}
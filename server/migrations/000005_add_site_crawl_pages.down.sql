@@ -0,0 +1,10 @@
+ALTER TABLE links
+  DROP FOREIGN KEY fk_links_page,
+  DROP INDEX idx_links_page_id,
+  DROP COLUMN page_id;
+
+DROP TABLE pages;
+
+ALTER TABLE urls
+  DROP COLUMN max_depth,
+  DROP COLUMN max_pages;
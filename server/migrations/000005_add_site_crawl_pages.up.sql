@@ -0,0 +1,33 @@
+-- 1) crawl limits per submitted URL (defaults keep single-page behaviour)
+ALTER TABLE urls
+  ADD COLUMN max_depth INT NOT NULL DEFAULT 0,
+  ADD COLUMN max_pages INT NOT NULL DEFAULT 1;
+
+-- 2) one row per crawled page, linked to its parent page
+CREATE TABLE pages (
+  id             BIGINT PRIMARY KEY AUTO_INCREMENT,
+  url_id         BIGINT NOT NULL,
+  parent_id      BIGINT NULL,
+  url            VARCHAR(2048) NOT NULL,
+  depth          INT DEFAULT 0,
+  html_version   VARCHAR(16),
+  title          VARCHAR(512),
+  h1             INT DEFAULT 0,
+  h2             INT DEFAULT 0,
+  h3             INT DEFAULT 0,
+  internal_links INT DEFAULT 0,
+  external_links INT DEFAULT 0,
+  broken_links   INT DEFAULT 0,
+  has_login      BOOL DEFAULT FALSE,
+  created_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_pages_url_id (url_id),
+  CONSTRAINT fk_pages_url FOREIGN KEY (url_id)
+    REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- 3) links remember which page they were found on
+ALTER TABLE links
+  ADD COLUMN page_id BIGINT NULL,
+  ADD INDEX idx_links_page_id (page_id),
+  ADD CONSTRAINT fk_links_page FOREIGN KEY (page_id)
+    REFERENCES pages(id) ON DELETE CASCADE;
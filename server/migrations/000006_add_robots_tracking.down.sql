@@ -0,0 +1,3 @@
+ALTER TABLE links DROP COLUMN check_status;
+ALTER TABLE pages DROP COLUMN blocked_by_robots;
+ALTER TABLE urls  DROP COLUMN blocked_by_robots;
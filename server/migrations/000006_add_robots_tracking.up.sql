@@ -0,0 +1,9 @@
+ALTER TABLE urls
+  ADD COLUMN blocked_by_robots INT DEFAULT 0;
+
+ALTER TABLE pages
+  ADD COLUMN blocked_by_robots INT DEFAULT 0;
+
+-- checked | blocked_by_robots (http_status stays NULL when blocked)
+ALTER TABLE links
+  ADD COLUMN check_status VARCHAR(32) NOT NULL DEFAULT 'checked';
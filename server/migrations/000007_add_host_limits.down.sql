@@ -0,0 +1,3 @@
+ALTER TABLE urls
+  DROP COLUMN host_concurrency,
+  DROP COLUMN host_interval_ms;
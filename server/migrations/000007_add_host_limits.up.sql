@@ -0,0 +1,4 @@
+-- per-URL overrides of the per-host politeness limits (0 = server default)
+ALTER TABLE urls
+  ADD COLUMN host_concurrency INT NOT NULL DEFAULT 0,
+  ADD COLUMN host_interval_ms INT NOT NULL DEFAULT 0;
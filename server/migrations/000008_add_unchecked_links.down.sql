@@ -0,0 +1,2 @@
+ALTER TABLE pages DROP COLUMN unchecked_links;
+ALTER TABLE urls  DROP COLUMN unchecked_links;
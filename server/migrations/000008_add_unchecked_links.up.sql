@@ -0,0 +1,6 @@
+-- links still pending when the crawl deadline fired
+ALTER TABLE urls
+  ADD COLUMN unchecked_links INT DEFAULT 0;
+
+ALTER TABLE pages
+  ADD COLUMN unchecked_links INT DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE links
+  DROP COLUMN method,
+  DROP COLUMN attempts;
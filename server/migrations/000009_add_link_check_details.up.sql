@@ -0,0 +1,4 @@
+-- how the link status was obtained
+ALTER TABLE links
+  ADD COLUMN method   VARCHAR(8) NULL,
+  ADD COLUMN attempts INT DEFAULT 0;
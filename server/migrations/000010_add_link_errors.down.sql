@@ -0,0 +1,4 @@
+ALTER TABLE links
+  DROP INDEX idx_links_error_kind,
+  DROP COLUMN error_kind,
+  DROP COLUMN error_message;
@@ -0,0 +1,5 @@
+-- why a link check failed without an HTTP status
+ALTER TABLE links
+  ADD COLUMN error_kind    VARCHAR(32)   NULL,
+  ADD COLUMN error_message VARCHAR(1024) NULL,
+  ADD INDEX idx_links_error_kind (error_kind);
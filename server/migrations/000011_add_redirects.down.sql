@@ -0,0 +1,12 @@
+ALTER TABLE urls
+  DROP COLUMN redirected_links,
+  DROP COLUMN final_url,
+  DROP COLUMN redirect_loop;
+
+ALTER TABLE pages DROP COLUMN redirected_links;
+
+ALTER TABLE links
+  DROP COLUMN redirect_loop,
+  DROP COLUMN long_redirect;
+
+DROP TABLE redirects;
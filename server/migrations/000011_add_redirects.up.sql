@@ -0,0 +1,28 @@
+-- one row per hop; link_id NULL = chain of the submitted URL itself
+CREATE TABLE redirects (
+  id        BIGINT PRIMARY KEY AUTO_INCREMENT,
+  url_id    BIGINT NOT NULL,
+  link_id   BIGINT NULL,
+  hop       INT NOT NULL,
+  url       VARCHAR(2048) NOT NULL,
+  status    SMALLINT NOT NULL,
+  location  VARCHAR(2048),
+  INDEX idx_redirects_url_id (url_id),
+  INDEX idx_redirects_link_id (link_id),
+  CONSTRAINT fk_redirects_url FOREIGN KEY (url_id)
+    REFERENCES urls(id) ON DELETE CASCADE,
+  CONSTRAINT fk_redirects_link FOREIGN KEY (link_id)
+    REFERENCES links(id) ON DELETE CASCADE
+);
+
+ALTER TABLE links
+  ADD COLUMN redirect_loop BOOL DEFAULT FALSE,
+  ADD COLUMN long_redirect BOOL DEFAULT FALSE;
+
+ALTER TABLE pages
+  ADD COLUMN redirected_links INT DEFAULT 0;
+
+ALTER TABLE urls
+  ADD COLUMN redirected_links INT DEFAULT 0,
+  ADD COLUMN final_url        VARCHAR(2048) NULL,
+  ADD COLUMN redirect_loop    BOOL DEFAULT FALSE;
@@ -0,0 +1,14 @@
+-- keeps only the latest run's rows, as before runs existed
+DELETE l FROM links l JOIN urls u ON u.id = l.url_id
+  WHERE l.run_id IS NOT NULL AND l.run_id <> u.latest_run_id;
+DELETE p FROM pages p JOIN urls u ON u.id = p.url_id
+  WHERE p.run_id IS NOT NULL AND p.run_id <> u.latest_run_id;
+DELETE d FROM redirects d JOIN urls u ON u.id = d.url_id
+  WHERE d.run_id IS NOT NULL AND d.run_id <> u.latest_run_id;
+
+ALTER TABLE redirects DROP INDEX idx_redirects_run_id, DROP COLUMN run_id;
+ALTER TABLE links     DROP INDEX idx_links_run_id,     DROP COLUMN run_id;
+ALTER TABLE pages     DROP INDEX idx_pages_run_id,     DROP COLUMN run_id;
+ALTER TABLE urls      DROP COLUMN latest_run_id;
+
+DROP TABLE crawl_runs;
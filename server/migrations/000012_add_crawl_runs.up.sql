@@ -0,0 +1,48 @@
+-- 1) one immutable row per crawl
+CREATE TABLE crawl_runs (
+  id                BIGINT PRIMARY KEY AUTO_INCREMENT,
+  url_id            BIGINT NOT NULL,
+  status            VARCHAR(16) NOT NULL DEFAULT 'running',
+  started_at        TIMESTAMP NULL,
+  finished_at       TIMESTAMP NULL,
+  html_version      VARCHAR(16),
+  title             VARCHAR(512),
+  h1                INT DEFAULT 0,
+  h2                INT DEFAULT 0,
+  h3                INT DEFAULT 0,
+  internal_links    INT DEFAULT 0,
+  external_links    INT DEFAULT 0,
+  broken_links      INT DEFAULT 0,
+  blocked_by_robots INT DEFAULT 0,
+  unchecked_links   INT DEFAULT 0,
+  redirected_links  INT DEFAULT 0,
+  final_url         VARCHAR(2048) NULL,
+  redirect_loop     BOOL DEFAULT FALSE,
+  has_login         BOOL DEFAULT FALSE,
+  INDEX idx_crawl_runs_url_id (url_id),
+  CONSTRAINT fk_crawl_runs_url FOREIGN KEY (url_id)
+    REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- 2) results point at the run they belong to
+ALTER TABLE urls      ADD COLUMN latest_run_id BIGINT NULL;
+ALTER TABLE pages     ADD COLUMN run_id BIGINT NULL, ADD INDEX idx_pages_run_id (run_id);
+ALTER TABLE links     ADD COLUMN run_id BIGINT NULL, ADD INDEX idx_links_run_id (run_id);
+ALTER TABLE redirects ADD COLUMN run_id BIGINT NULL, ADD INDEX idx_redirects_run_id (run_id);
+
+-- 3) turn every finished crawl into its first run
+INSERT INTO crawl_runs (url_id, status, started_at, finished_at, html_version, title,
+                        h1, h2, h3, internal_links, external_links, broken_links,
+                        blocked_by_robots, unchecked_links, redirected_links,
+                        final_url, redirect_loop, has_login)
+SELECT id, crawl_status, updated_at, updated_at, html_version, title,
+       h1, h2, h3, internal_links, external_links, broken_links,
+       blocked_by_robots, unchecked_links, redirected_links,
+       final_url, redirect_loop, has_login
+FROM urls
+WHERE crawl_status IN ('done', 'error');
+
+UPDATE urls u JOIN crawl_runs r ON r.url_id = u.id SET u.latest_run_id = r.id;
+UPDATE pages p JOIN crawl_runs r ON r.url_id = p.url_id SET p.run_id = r.id;
+UPDATE links l JOIN crawl_runs r ON r.url_id = l.url_id SET l.run_id = r.id;
+UPDATE redirects d JOIN crawl_runs r ON r.url_id = d.url_id SET d.run_id = r.id;
@@ -0,0 +1,6 @@
+ALTER TABLE urls
+  DROP INDEX idx_urls_next_run_at,
+  DROP COLUMN schedule,
+  DROP COLUMN schedule_paused,
+  DROP COLUMN next_run_at,
+  DROP COLUMN last_run_at;
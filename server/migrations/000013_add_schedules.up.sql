@@ -0,0 +1,7 @@
+-- recurring crawls: cron spec + when the scheduler should next enqueue
+ALTER TABLE urls
+  ADD COLUMN schedule        VARCHAR(128) NULL,
+  ADD COLUMN schedule_paused BOOL NOT NULL DEFAULT FALSE,
+  ADD COLUMN next_run_at     TIMESTAMP NULL,
+  ADD COLUMN last_run_at     TIMESTAMP NULL,
+  ADD INDEX idx_urls_next_run_at (next_run_at);
@@ -0,0 +1,20 @@
+-- durable crawl queue; workers lease rows instead of reading a channel
+CREATE TABLE jobs (
+  id          BIGINT PRIMARY KEY AUTO_INCREMENT,
+  url_id      BIGINT NOT NULL,
+  state       VARCHAR(16) NOT NULL DEFAULT 'queued',
+  attempts    INT NOT NULL DEFAULT 0,
+  worker_id   VARCHAR(128) NULL,
+  lease_until TIMESTAMP NULL,
+  last_error  VARCHAR(1024) NULL,
+  created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_jobs_url_id (url_id),
+  INDEX idx_jobs_state (state),
+  CONSTRAINT fk_jobs_url FOREIGN KEY (url_id)
+    REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- URLs left 'queued' by the old in-memory channel get a job
+INSERT INTO jobs (url_id, state)
+SELECT id, 'queued' FROM urls WHERE crawl_status = 'queued';
@@ -0,0 +1,6 @@
+ALTER TABLE jobs
+  DROP INDEX idx_jobs_user_claimed,
+  DROP INDEX idx_jobs_priority,
+  DROP COLUMN claimed_at,
+  DROP COLUMN priority,
+  DROP COLUMN user_id;
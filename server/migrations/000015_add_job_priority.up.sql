@@ -0,0 +1,10 @@
+-- priority levels + per-user round-robin when claiming jobs;
+-- claimed_at keeps microseconds so users served in the same second still alternate
+ALTER TABLE jobs
+  ADD COLUMN user_id    BIGINT NOT NULL DEFAULT 0 AFTER url_id,
+  ADD COLUMN priority   INT NOT NULL DEFAULT 0 AFTER user_id,
+  ADD COLUMN claimed_at TIMESTAMP(6) NULL AFTER worker_id,
+  ADD INDEX idx_jobs_priority (priority),
+  ADD INDEX idx_jobs_user_claimed (user_id, claimed_at);
+
+UPDATE jobs j JOIN urls u ON u.id = j.url_id SET j.user_id = u.user_id;
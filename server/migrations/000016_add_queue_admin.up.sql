@@ -0,0 +1,8 @@
+-- admins may inspect and control the crawl queue
+ALTER TABLE users ADD COLUMN is_admin BOOL NOT NULL DEFAULT FALSE;
+
+-- process-wide switches, e.g. queue_paused
+CREATE TABLE settings (
+  `key`   VARCHAR(64) PRIMARY KEY,
+  `value` VARCHAR(255) NOT NULL DEFAULT ''
+);
@@ -0,0 +1 @@
+DROP TABLE crawl_signals;
@@ -0,0 +1,9 @@
+-- progress & cancel messages relayed between API and worker processes
+CREATE TABLE crawl_signals (
+  id         BIGINT PRIMARY KEY AUTO_INCREMENT,
+  url_id     BIGINT NOT NULL,
+  kind       VARCHAR(16) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_crawl_signals_url_id (url_id),
+  INDEX idx_crawl_signals_created_at (created_at)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE jobs DROP COLUMN cancel_requested_at;
+
+UPDATE urls SET crawl_status = 'error' WHERE crawl_status = 'cancelled';
+ALTER TABLE urls
+  MODIFY crawl_status ENUM('queued','running','done','error') DEFAULT 'queued';
@@ -0,0 +1,6 @@
+-- stopped crawls get their own status instead of 'error'
+ALTER TABLE urls
+  MODIFY crawl_status ENUM('queued','running','done','error','cancelled') DEFAULT 'queued';
+
+-- durable stop request, honoured by whichever worker holds the job
+ALTER TABLE jobs ADD COLUMN cancel_requested_at TIMESTAMP NULL AFTER lease_until;
@@ -0,0 +1,2 @@
+DELETE FROM crawl_signals WHERE kind = 'event';
+ALTER TABLE crawl_signals DROP COLUMN payload;
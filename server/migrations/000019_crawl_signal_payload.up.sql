@@ -0,0 +1,4 @@
+-- signals carry typed crawl events as JSON; rows written before have
+-- none and are short-lived, so they are simply dropped
+DELETE FROM crawl_signals WHERE kind = 'progress';
+ALTER TABLE crawl_signals ADD COLUMN payload TEXT NULL;
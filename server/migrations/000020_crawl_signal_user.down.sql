@@ -0,0 +1,4 @@
+DELETE FROM crawl_signals WHERE kind = 'status';
+ALTER TABLE crawl_signals
+  DROP INDEX idx_crawl_signals_user_id,
+  DROP COLUMN user_id;
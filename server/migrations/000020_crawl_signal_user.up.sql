@@ -0,0 +1,4 @@
+-- lets a user's multiplexed stream follow all of their crawls
+ALTER TABLE crawl_signals
+  ADD COLUMN user_id BIGINT NOT NULL DEFAULT 0 AFTER url_id,
+  ADD INDEX idx_crawl_signals_user_id (user_id);
@@ -0,0 +1,47 @@
+-- endpoints users want told about finished crawls
+CREATE TABLE webhooks (
+  id         BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id    BIGINT NOT NULL,
+  url        VARCHAR(2048) NOT NULL,
+  secret     VARCHAR(64) NOT NULL,
+  events     VARCHAR(128) NOT NULL,
+  created_at TIMESTAMP NULL,
+  updated_at TIMESTAMP NULL,
+  INDEX idx_webhooks_user_id (user_id),
+  CONSTRAINT fk_webhooks_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- one row per event to send; the dispatcher retries until delivered or failed
+CREATE TABLE webhook_deliveries (
+  id              BIGINT PRIMARY KEY AUTO_INCREMENT,
+  webhook_id      BIGINT NOT NULL,
+  url_id          BIGINT NOT NULL,
+  run_id          BIGINT NOT NULL,
+  event           VARCHAR(32) NOT NULL,
+  payload         TEXT NOT NULL,
+  state           VARCHAR(16) NOT NULL DEFAULT 'pending',
+  attempts        INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NULL,
+  delivered_at    TIMESTAMP NULL,
+  created_at      TIMESTAMP NULL,
+  INDEX idx_webhook_deliveries_webhook_id (webhook_id),
+  INDEX idx_webhook_deliveries_state (state),
+  INDEX idx_webhook_deliveries_next_attempt_at (next_attempt_at),
+  CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id)
+    REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+-- every POST of a delivery
+CREATE TABLE webhook_attempts (
+  id          BIGINT PRIMARY KEY AUTO_INCREMENT,
+  delivery_id BIGINT NOT NULL,
+  attempt     INT NOT NULL,
+  status_code INT NULL,
+  error       VARCHAR(1024) NULL,
+  duration_ms BIGINT NOT NULL DEFAULT 0,
+  created_at  TIMESTAMP NULL,
+  INDEX idx_webhook_attempts_delivery_id (delivery_id),
+  CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id)
+    REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+);
@@ -0,0 +1,53 @@
+-- rules like "broken_links > 0", per URL or (url_id NULL) for all of a user's URLs
+CREATE TABLE alert_rules (
+  id         BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id    BIGINT NOT NULL,
+  url_id     BIGINT NULL,
+  metric     VARCHAR(32) NOT NULL,
+  op         VARCHAR(2) NOT NULL,
+  threshold  INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NULL,
+  INDEX idx_alert_rules_user_id (user_id),
+  INDEX idx_alert_rules_url_id (url_id),
+  CONSTRAINT fk_alert_rules_user FOREIGN KEY (user_id)
+    REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_alert_rules_url FOREIGN KEY (url_id)
+    REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- rules that fired; they outlive their rule
+CREATE TABLE alerts (
+  id              BIGINT PRIMARY KEY AUTO_INCREMENT,
+  rule_id         BIGINT NOT NULL,
+  user_id         BIGINT NOT NULL,
+  url_id          BIGINT NOT NULL,
+  run_id          BIGINT NOT NULL,
+  metric          VARCHAR(32) NOT NULL,
+  op              VARCHAR(2) NOT NULL,
+  threshold       INT NOT NULL DEFAULT 0,
+  value           INT NOT NULL DEFAULT 0,
+  state           VARCHAR(16) NOT NULL DEFAULT 'open',
+  acknowledged_at TIMESTAMP NULL,
+  resolved_at     TIMESTAMP NULL,
+  created_at      TIMESTAMP NULL,
+  updated_at      TIMESTAMP NULL,
+  INDEX idx_alerts_rule_id (rule_id),
+  INDEX idx_alerts_user_id (user_id),
+  INDEX idx_alerts_url_id (url_id),
+  INDEX idx_alerts_state (state),
+  CONSTRAINT fk_alerts_url FOREIGN KEY (url_id)
+    REFERENCES urls(id) ON DELETE CASCADE
+);
+
+-- the links that made an alert fire
+CREATE TABLE alert_evidences (
+  id          BIGINT PRIMARY KEY AUTO_INCREMENT,
+  alert_id    BIGINT NOT NULL,
+  href        VARCHAR(2048),
+  http_status INT NULL,
+  error_kind  VARCHAR(32),
+  is_internal BOOL DEFAULT FALSE,
+  INDEX idx_alert_evidences_alert_id (alert_id),
+  CONSTRAINT fk_alert_evidences_alert FOREIGN KEY (alert_id)
+    REFERENCES alerts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- the row Enqueue locks (SELECT … FOR UPDATE) to check capacity and insert atomically
+INSERT INTO settings (`key`, `value`) VALUES ('queue_lock', '');